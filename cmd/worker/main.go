@@ -0,0 +1,49 @@
+// Command worker runs job workers, the cron scheduler and the lease reaper
+// without the HTTP API, so workers can be scaled apart from the API tier.
+// It takes the same configuration as cmd/server and must use the same
+// database.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/arthures11/gosynq"
+	"github.com/arthures11/gosynq/internal/config"
+	"github.com/arthures11/gosynq/internal/demojobs"
+)
+
+func main() {
+	// Load configuration from the config file, GOSYNQ_* variables and flags
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if cfg.Server.APIOnly {
+		log.Fatalf("Invalid configuration: server.api_only leaves a worker with nothing to do")
+	}
+
+	// No HTTP listener: the API is served by cmd/server
+	opts := []gosynq.Option{
+		gosynq.WithConfig(cfg),
+	}
+	opts = append(opts, demojobs.Options()...)
+
+	srv, err := gosynq.NewServer(opts...)
+	if err != nil {
+		log.Fatalf("Failed to create worker: %v", err)
+	}
+
+	// Set up graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Worker node starting with %d workers", cfg.Worker.PoolSize)
+	if err := srv.Run(ctx); err != nil {
+		log.Printf("Worker shutdown error: %v", err)
+	}
+}
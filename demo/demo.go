@@ -1,24 +1,18 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math/rand"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
-)
 
-type DemoJob struct {
-	Queue      string      `json:"queue"`
-	Payload    interface{} `json:"payload"`
-	MaxRetries int         `json:"max_retries"`
-	Priority   string      `json:"priority"`
-}
+	"github.com/arthures11/gosynq/pkg/client"
+)
 
 func main() {
 	log.Println("Starting Mini Asynq Demo...")
@@ -28,14 +22,14 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Start demo job generation
-	go generateDemoJobs()
+	go generateDemoJobs(client.New("http://localhost:8080"))
 
 	// Wait for shutdown signal
 	<-sigChan
 	log.Println("Shutting down demo...")
 }
 
-func generateDemoJobs() {
+func generateDemoJobs(c *client.Client) {
 	// Sample job types
 	jobTypes := []string{
 		"email_notification",
@@ -106,16 +100,8 @@ func generateDemoJobs() {
 			// Random retries (0-5)
 			retries := rand.Intn(6)
 
-			// Create job
-			job := DemoJob{
-				Queue:      queue,
-				Payload:    payload,
-				MaxRetries: retries,
-				Priority:   priority,
-			}
-
 			// Send to API
-			err := enqueueJob(job)
+			err := enqueueJob(c, jobType, queue, priority, retries, payload)
 			if err != nil {
 				log.Printf("Failed to enqueue job: %v", err)
 			} else {
@@ -125,32 +111,21 @@ func generateDemoJobs() {
 	}
 }
 
-func enqueueJob(job DemoJob) error {
-	// Convert job to JSON
-	jsonData, err := json.Marshal(job)
-	if err != nil {
-		return fmt.Errorf("failed to marshal job: %w", err)
-	}
-
-	// Create HTTP request
-	req, err := http.NewRequest("POST", "http://localhost:8080/api/v1/jobs", bytes.NewBuffer(jsonData))
+func enqueueJob(c *client.Client, jobType, queue, priority string, retries int, payload interface{}) error {
+	data, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = c.Enqueue(ctx, client.EnqueueRequest{
+		Queue:      queue,
+		Type:       jobType,
+		Payload:    data,
+		MaxRetries: retries,
+		Priority:   client.JobPriority(priority),
+	})
+	return err
 }
@@ -23,7 +23,7 @@ export class DashboardComponent implements OnInit {
     pendingJobs: 0,
     processingJobs: 0,
     completedJobs: 0,
-    failedJobs: 0
+    deadJobs: 0
   };
 
   constructor(private apiService: ApiService) { }
@@ -57,7 +57,7 @@ export class DashboardComponent implements OnInit {
           pendingJobs: stats.pending_jobs || 0,
           processingJobs: stats.processing_jobs || 0,
           completedJobs: stats.completed_jobs || 0,
-          failedJobs: stats.failed_jobs || 0
+          deadJobs: stats.dead_jobs || 0
         };
       },
       error: (err) => {
@@ -124,7 +124,7 @@ export class JobsComponent implements OnInit, OnDestroy {
   getStatusColor(status: string): string {
     switch (status) {
       case 'completed': return 'bg-green-100 text-green-800';
-      case 'failed': return 'bg-red-100 text-red-800';
+      case 'dead': return 'bg-red-200 text-red-900';
       case 'cancelled': return 'bg-gray-100 text-gray-800';
       case 'processing': return 'bg-blue-100 text-blue-800';
       case 'pending': return 'bg-yellow-100 text-yellow-800';
@@ -135,7 +135,7 @@ export class JobsComponent implements OnInit, OnDestroy {
   getStatusBadge(status: string): string {
     switch (status) {
       case 'completed': return '✅ Completed';
-      case 'failed': return '❌ Failed';
+      case 'dead': return '💀 Dead';
       case 'cancelled': return '🚫 Cancelled';
       case 'processing': return '🔄 Processing';
       case 'pending': return '⏳ Pending';
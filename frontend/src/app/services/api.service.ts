@@ -6,6 +6,7 @@ import { environment } from '../../environments/environment';
 export interface Job {
   id: string;
   queue: string;
+  type: string;
   payload: any;
   max_retries: number;
   run_at: string;
@@ -16,6 +17,21 @@ export interface Job {
   idempotency_key: string;
   locked_by: string;
   locked_at?: string;
+  last_error?: string;
+  attempts: number;
+  snoozes: number;
+}
+
+export interface JobAttempt {
+  id: string;
+  job_id: string;
+  attempt_number: number;
+  worker_id: string;
+  started_at: string;
+  completed_at?: string;
+  status: string;
+  error_message?: string;
+  duration_ms: number;
 }
 
 @Injectable({
@@ -42,6 +58,11 @@ export class ApiService {
     return this.http.get<Job>(`${this.apiUrl}/jobs/${jobId}`);
   }
 
+  // Get a job's attempts, oldest first
+  getJobAttempts(jobId: string): Observable<JobAttempt[]> {
+    return this.http.get<JobAttempt[]>(`${this.apiUrl}/jobs/${jobId}/attempts`);
+  }
+
   // Create a new job
   createJob(jobData: {
     queue: string;
@@ -56,7 +77,7 @@ export class ApiService {
   // Cancel a job (admin)
   cancelJob(jobId: string): Observable<{ status: string }> {
     const headers = new HttpHeaders({
-      'Authorization': 'Basic ' + btoa('admin:password')
+      'Authorization': 'Basic ' + btoa(`${environment.adminUsername}:${environment.adminPassword}`)
     });
     return this.http.post<{ status: string }>(`${this.apiUrl}/admin/jobs/${jobId}/cancel`, {}, { headers });
   }
@@ -64,7 +85,7 @@ export class ApiService {
   // Retry a job (admin)
   retryJob(jobId: string): Observable<{ status: string }> {
     const headers = new HttpHeaders({
-      'Authorization': 'Basic ' + btoa('admin:password')
+      'Authorization': 'Basic ' + btoa(`${environment.adminUsername}:${environment.adminPassword}`)
     });
     return this.http.post<{ status: string }>(`${this.apiUrl}/admin/jobs/${jobId}/retry`, {}, { headers });
   }
@@ -80,16 +101,18 @@ export class ApiService {
     pending_jobs: number;
     processing_jobs: number;
     completed_jobs: number;
-    failed_jobs: number;
+    dead_jobs: number;
     cancelled_jobs: number;
+    paused_queues: string[];
   }> {
     return this.http.get<{
       total_jobs: number;
       pending_jobs: number;
       processing_jobs: number;
       completed_jobs: number;
-      failed_jobs: number;
+      dead_jobs: number;
       cancelled_jobs: number;
+      paused_queues: string[];
     }>(`${this.apiUrl}/stats`);
   }
 }
@@ -1,4 +1,7 @@
 export const environment = {
   production: true,
-  apiUrl: 'http://your-production-server.com/api/v1'
+  apiUrl: 'http://your-production-server.com/api/v1',
+  // Must match the server's admin.username and admin.password
+  adminUsername: '',
+  adminPassword: ''
 };
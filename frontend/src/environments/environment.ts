@@ -1,4 +1,7 @@
 export const environment = {
   production: false,
-  apiUrl: 'http://localhost:8080/api/v1'
+  apiUrl: 'http://localhost:8080/api/v1',
+  // Must match the server's admin.username and admin.password
+  adminUsername: 'admin',
+  adminPassword: 'password'
 };
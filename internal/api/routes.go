@@ -0,0 +1,804 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/config"
+	"github.com/arthures11/gosynq/internal/dispatcher"
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/repository"
+	"github.com/arthures11/gosynq/internal/scheduler"
+	"github.com/arthures11/gosynq/internal/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxRunDelay bounds how far ahead a job may be scheduled on enqueue.
+const maxRunDelay = 365 * 24 * time.Hour
+
+// resolveRunAt turns the optional run_at and run_in fields of an enqueue
+// request into the job's run time. With neither set the job runs now; a
+// run_at in the past also runs now.
+func resolveRunAt(runAt time.Time, runIn string, now time.Time) (time.Time, error) {
+	if !runAt.IsZero() && runIn != "" {
+		return time.Time{}, errors.New("run_at and run_in are mutually exclusive")
+	}
+
+	if runIn != "" {
+		delay, err := time.ParseDuration(runIn)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid run_in: %w", err)
+		}
+		if delay < 0 {
+			return time.Time{}, errors.New("run_in must not be negative")
+		}
+		runAt = now.Add(delay)
+	}
+
+	if runAt.IsZero() || runAt.Before(now) {
+		return now, nil
+	}
+	if runAt.Sub(now) > maxRunDelay {
+		return time.Time{}, errors.New("run_at must be within a year")
+	}
+
+	return runAt, nil
+}
+
+// enqueueRequest is one job to enqueue, sent alone or as an item of a
+// batch.
+type enqueueRequest struct {
+	Queue      string          `json:"queue"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	MaxRetries int             `json:"max_retries"`
+	Priority   string          `json:"priority"`
+	RunAt      time.Time       `json:"run_at"`
+	RunIn      string          `json:"run_in"`
+
+	IdempotencyKey string              `json:"idempotency_key"`
+	Retry          *models.RetryPolicy `json:"retry"`
+}
+
+// job validates the request and builds the job it describes.
+func (req *enqueueRequest) job(now time.Time) (*models.Job, error) {
+	if len(req.Payload) == 0 {
+		return nil, errors.New("payload is required")
+	}
+
+	runAt, err := resolveRunAt(req.RunAt, req.RunIn, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.IdempotencyKey) > 255 {
+		return nil, errors.New("idempotency_key must be at most 255 characters")
+	}
+	if req.Retry != nil {
+		if err := req.Retry.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.Job{
+		ID:             uuid.New().String(),
+		Queue:          req.Queue,
+		Type:           req.Type,
+		Payload:        req.Payload,
+		MaxRetries:     req.MaxRetries,
+		Priority:       models.JobPriority(req.Priority),
+		RunAt:          runAt,
+		Status:         models.StatusPending,
+		IdempotencyKey: req.IdempotencyKey,
+		RetryPolicy:    req.Retry,
+	}, nil
+}
+
+// maxBatchSize caps the number of jobs in one batch enqueue.
+const maxBatchSize = 10000
+
+// batchResult reports what became of one item of a batch enqueue.
+type batchResult struct {
+	Index  int        `json:"index"`
+	JobID  string     `json:"job_id,omitempty"`
+	Status string     `json:"status"`
+	RunAt  *time.Time `json:"run_at,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// readBatch splits a batch enqueue body into its items without decoding
+// them, so a malformed item fails alone. The body is a JSON array, or NDJSON
+// with one job per line when sent as application/x-ndjson.
+func readBatch(c *gin.Context) ([]json.RawMessage, error) {
+	tooLarge := fmt.Errorf("a batch may hold at most %d jobs", maxBatchSize)
+
+	if c.ContentType() != "application/x-ndjson" {
+		var items []json.RawMessage
+		if err := json.NewDecoder(c.Request.Body).Decode(&items); err != nil {
+			return nil, fmt.Errorf("body must be a JSON array of jobs: %w", err)
+		}
+		if len(items) > maxBatchSize {
+			return nil, tooLarge
+		}
+		return items, nil
+	}
+
+	var items []json.RawMessage
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(items) == maxBatchSize {
+			return nil, tooLarge
+		}
+		items = append(items, bytes.Clone(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	return items, nil
+}
+
+// scheduleRequest is the body accepted when creating or replacing a
+// schedule. Enabled defaults to true on create and is left unchanged on
+// update when omitted.
+type scheduleRequest struct {
+	Name       string          `json:"name"`
+	Cron       string          `json:"cron"`
+	Timezone   string          `json:"timezone"`
+	Queue      string          `json:"queue"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Priority   string          `json:"priority"`
+	MaxRetries int             `json:"max_retries"`
+	Enabled    *bool           `json:"enabled"`
+}
+
+// nonNilAttempts makes a job without attempts serialize as [] rather than
+// null.
+func nonNilAttempts(attempts []*models.JobAttempt) []*models.JobAttempt {
+	if attempts == nil {
+		return []*models.JobAttempt{}
+	}
+	return attempts
+}
+
+// deadJobsRequest selects dead jobs for bulk requeue and purge. With no
+// body, or an empty one, every dead job is selected.
+type deadJobsRequest struct {
+	IDs   []string `json:"ids"`
+	Queue string   `json:"queue"`
+}
+
+func bindDeadJobsRequest(c *gin.Context) (repository.DeadJobFilter, error) {
+	var req deadJobsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return repository.DeadJobFilter{}, err
+		}
+	}
+	return repository.DeadJobFilter{IDs: req.IDs, Queue: req.Queue}, nil
+}
+
+func (req *scheduleRequest) apply(schedule *models.Schedule) {
+	schedule.Name = req.Name
+	schedule.Cron = req.Cron
+	schedule.Timezone = req.Timezone
+	schedule.Queue = req.Queue
+	schedule.Type = req.Type
+	schedule.Payload = req.Payload
+	schedule.Priority = models.JobPriority(req.Priority)
+	schedule.MaxRetries = req.MaxRetries
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+}
+
+// NewRouter builds the HTTP API. All routes live under /api/v1 so the
+// router can be mounted alongside other handlers. The admin routes and the
+// schedule writes answer 403 when auth has no credentials.
+func NewRouter(disp *dispatcher.Dispatcher, repo repository.Store, wsServer *websocket.WebSocketServer, auth config.AdminConfig) *gin.Engine {
+	router := gin.Default()
+
+	// Add CORS middleware
+	router.Use(func(c *gin.Context) {
+		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	})
+
+	adminAuth := func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin API is disabled"})
+	}
+	if auth.Username != "" && auth.Password != "" {
+		adminAuth = gin.BasicAuth(gin.Accounts{auth.Username: auth.Password})
+	}
+
+	// API routes
+	api := router.Group("/api/v1")
+	{
+		jobs := api.Group("/jobs")
+		{
+			jobs.POST("", func(c *gin.Context) {
+				// Enqueue job endpoint
+				var req enqueueRequest
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+
+				// The key may also be sent as an Idempotency-Key header
+				if req.IdempotencyKey == "" {
+					req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+				}
+
+				job, err := req.job(time.Now())
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+
+				existing, err := disp.EnqueueJob(c.Request.Context(), job)
+				if errors.Is(err, models.ErrInvalidRetryPolicy) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				if errors.Is(err, dispatcher.ErrClosed) {
+					c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+					return
+				}
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				if existing != nil {
+					// Duplicate: answer with the job that holds the key
+					c.JSON(http.StatusOK, gin.H{
+						"job_id": existing.ID,
+						"status": "duplicate",
+						"run_at": existing.RunAt,
+					})
+					return
+				}
+
+				status := "queued"
+				if job.RunAt.After(time.Now()) {
+					status = "scheduled"
+				}
+
+				c.JSON(http.StatusCreated, gin.H{
+					"job_id": job.ID,
+					"status": status,
+					"run_at": job.RunAt,
+				})
+			})
+
+			jobs.POST("/batch", func(c *gin.Context) {
+				// Bulk enqueue endpoint: invalid items are reported and
+				// skipped, the rest are stored together
+				items, err := readBatch(c)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				if len(items) == 0 {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "batch must contain at least one job"})
+					return
+				}
+
+				now := time.Now()
+				results := make([]batchResult, len(items))
+				var valid []*models.Job
+				var validIndex []int
+				for i, item := range items {
+					results[i].Index = i
+
+					var req enqueueRequest
+					err := json.Unmarshal(item, &req)
+					var job *models.Job
+					if err == nil {
+						job, err = req.job(now)
+					}
+					if err != nil {
+						results[i].Status = "invalid"
+						results[i].Error = err.Error()
+						continue
+					}
+
+					valid = append(valid, job)
+					validIndex = append(validIndex, i)
+				}
+
+				if len(valid) > 0 {
+					existing, err := disp.EnqueueJobs(c.Request.Context(), valid)
+					if errors.Is(err, dispatcher.ErrClosed) {
+						c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+						return
+					}
+					if err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+
+					for j, job := range valid {
+						result := &results[validIndex[j]]
+						if existing[j] != nil {
+							job = existing[j]
+							result.Status = "duplicate"
+						} else if job.RunAt.After(now) {
+							result.Status = "scheduled"
+						} else {
+							result.Status = "queued"
+						}
+						result.JobID = job.ID
+						result.RunAt = &job.RunAt
+					}
+				}
+
+				counts := map[string]int{"queued": 0, "scheduled": 0, "duplicate": 0, "invalid": 0}
+				for _, result := range results {
+					counts[result.Status]++
+				}
+
+				c.JSON(http.StatusOK, gin.H{
+					"enqueued":   counts["queued"] + counts["scheduled"],
+					"duplicates": counts["duplicate"],
+					"invalid":    counts["invalid"],
+					"results":    results,
+				})
+			})
+
+			jobs.GET("", func(c *gin.Context) {
+				// List jobs endpoint
+				filter := repository.JobFilter{
+					Status: c.Query("status"),
+					Queue:  c.Query("queue"),
+					Type:   c.Query("type"),
+					Limit:  100,
+				}
+				// "scheduled" is a view, not a stored status: pending jobs
+				// whose run_at is still in the future.
+				if filter.Status == "scheduled" {
+					filter.Status = string(models.StatusPending)
+					filter.Scheduled = true
+				}
+				if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 && limit <= 1000 {
+					filter.Limit = limit
+				}
+
+				jobs, err := repo.ListJobs(c.Request.Context(), filter)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				if jobs == nil {
+					jobs = []*models.Job{}
+				}
+
+				c.JSON(http.StatusOK, jobs)
+			})
+
+			jobs.GET("/:id", func(c *gin.Context) {
+				// Get job details
+				jobID := c.Param("id")
+
+				job, err := repo.GetJobByID(c.Request.Context(), jobID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				if job == nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+					return
+				}
+
+				if c.Query("include") != "attempts" {
+					c.JSON(http.StatusOK, job)
+					return
+				}
+
+				attempts, err := repo.GetJobAttempts(c.Request.Context(), jobID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+
+				// The job's own "attempts" field is the count, so the
+				// history goes under its own key
+				c.JSON(http.StatusOK, struct {
+					*models.Job
+					AttemptHistory []*models.JobAttempt `json:"attempt_history"`
+				}{job, nonNilAttempts(attempts)})
+			})
+
+			jobs.GET("/:id/attempts", func(c *gin.Context) {
+				// Attempt history, oldest first
+				jobID := c.Param("id")
+
+				job, err := repo.GetJobByID(c.Request.Context(), jobID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				if job == nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+					return
+				}
+
+				attempts, err := repo.GetJobAttempts(c.Request.Context(), jobID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+
+				c.JSON(http.StatusOK, nonNilAttempts(attempts))
+			})
+
+			// Admin endpoints
+			admin := api.Group("/admin", adminAuth)
+			{
+				admin.POST("/jobs/:id/retry", func(c *gin.Context) {
+					jobID := c.Param("id")
+
+					// Get current job
+					job, err := repo.GetJobByID(c.Request.Context(), jobID)
+					if err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+					if job == nil {
+						c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+						return
+					}
+
+					// Requeue it like the dead-letter endpoint does, so the
+					// retry starts with fresh attempts
+					requeued := 0
+					if job.Status == models.StatusDead {
+						requeued, err = repo.RequeueDeadJobs(c.Request.Context(), repository.DeadJobFilter{IDs: []string{jobID}})
+						if err != nil {
+							c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+							return
+						}
+					}
+					if requeued == 0 {
+						c.JSON(http.StatusBadRequest, gin.H{"error": "only dead jobs can be retried"})
+						return
+					}
+
+					c.JSON(http.StatusOK, gin.H{"status": "retry scheduled"})
+				})
+
+				admin.POST("/jobs/:id/cancel", func(c *gin.Context) {
+					jobID := c.Param("id")
+
+					// Cancel the job
+					err := repo.UpdateJobStatus(c.Request.Context(), jobID, models.StatusCancelled)
+					if err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+
+					c.JSON(http.StatusOK, gin.H{"status": "job cancelled"})
+				})
+
+				admin.PUT("/queues/:queue", func(c *gin.Context) {
+					queue := c.Param("queue")
+
+					// retry is optional; {} clears the queue's policy
+					var req struct {
+						Weight      int                 `json:"weight"`
+						MaxInFlight int                 `json:"max_in_flight"`
+						Retry       *models.RetryPolicy `json:"retry"`
+					}
+
+					if err := c.ShouldBindJSON(&req); err != nil {
+						c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+						return
+					}
+					if req.Retry != nil {
+						if err := req.Retry.Validate(); err != nil {
+							c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+							return
+						}
+					}
+
+					err := disp.ConfigureQueue(c.Request.Context(), queue, req.Weight, req.MaxInFlight)
+					if errors.Is(err, dispatcher.ErrInvalidQueueConfig) {
+						c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+						return
+					}
+					if err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+
+					if req.Retry != nil {
+						if err := disp.SetQueueRetryPolicy(c.Request.Context(), queue, req.Retry); err != nil {
+							c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+							return
+						}
+					}
+
+					c.JSON(http.StatusOK, gin.H{"status": "queue configured", "queue": queue})
+				})
+
+				admin.POST("/queues/:queue/pause", func(c *gin.Context) {
+					queue := c.Param("queue")
+
+					if err := disp.PauseQueue(c.Request.Context(), queue); err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+
+					c.JSON(http.StatusOK, gin.H{"status": "queue paused", "queue": queue})
+				})
+
+				admin.POST("/queues/:queue/resume", func(c *gin.Context) {
+					queue := c.Param("queue")
+
+					if err := disp.ResumeQueue(c.Request.Context(), queue); err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+
+					c.JSON(http.StatusOK, gin.H{"status": "queue resumed", "queue": queue})
+				})
+
+				// Dead-letter queue: jobs that ran out of retries
+				admin.GET("/dead", func(c *gin.Context) {
+					filter := repository.JobFilter{
+						Status: string(models.StatusDead),
+						Queue:  c.Query("queue"),
+						Type:   c.Query("type"),
+						Limit:  100,
+					}
+					if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 && limit <= 1000 {
+						filter.Limit = limit
+					}
+
+					jobs, err := repo.ListJobs(c.Request.Context(), filter)
+					if err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+					if jobs == nil {
+						jobs = []*models.Job{}
+					}
+
+					c.JSON(http.StatusOK, jobs)
+				})
+
+				admin.POST("/dead/requeue", func(c *gin.Context) {
+					filter, err := bindDeadJobsRequest(c)
+					if err != nil {
+						c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+						return
+					}
+
+					requeued, err := repo.RequeueDeadJobs(c.Request.Context(), filter)
+					if err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+
+					c.JSON(http.StatusOK, gin.H{"requeued": requeued})
+				})
+
+				admin.DELETE("/dead", func(c *gin.Context) {
+					filter, err := bindDeadJobsRequest(c)
+					if err != nil {
+						c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+						return
+					}
+
+					purged, err := repo.PurgeDeadJobs(c.Request.Context(), filter)
+					if err != nil {
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+
+					c.JSON(http.StatusOK, gin.H{"purged": purged})
+				})
+			}
+		}
+
+		schedules := api.Group("/schedules")
+		{
+			sched := disp.Scheduler()
+
+			schedules.POST("", adminAuth, func(c *gin.Context) {
+				var req scheduleRequest
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+
+				schedule := &models.Schedule{Enabled: true}
+				req.apply(schedule)
+
+				err := sched.CreateSchedule(c.Request.Context(), schedule)
+				if errors.Is(err, scheduler.ErrInvalidSchedule) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+
+				c.JSON(http.StatusCreated, schedule)
+			})
+
+			schedules.GET("", func(c *gin.Context) {
+				list, err := repo.ListSchedules(c.Request.Context())
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+
+				if list == nil {
+					list = []*models.Schedule{}
+				}
+
+				c.JSON(http.StatusOK, list)
+			})
+
+			schedules.GET("/:id", func(c *gin.Context) {
+				schedule, err := repo.GetSchedule(c.Request.Context(), c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				if schedule == nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+					return
+				}
+
+				c.JSON(http.StatusOK, schedule)
+			})
+
+			schedules.PUT("/:id", adminAuth, func(c *gin.Context) {
+				var req scheduleRequest
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+
+				schedule, err := repo.GetSchedule(c.Request.Context(), c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				if schedule == nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+					return
+				}
+
+				req.apply(schedule)
+
+				err = sched.UpdateSchedule(c.Request.Context(), schedule)
+				if errors.Is(err, scheduler.ErrInvalidSchedule) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+
+				c.JSON(http.StatusOK, schedule)
+			})
+
+			schedules.DELETE("/:id", adminAuth, func(c *gin.Context) {
+				scheduleID := c.Param("id")
+
+				schedule, err := repo.GetSchedule(c.Request.Context(), scheduleID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				if schedule == nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+					return
+				}
+
+				if err := sched.DeleteSchedule(c.Request.Context(), scheduleID); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+
+				c.JSON(http.StatusOK, gin.H{"status": "schedule deleted"})
+			})
+		}
+
+		// Queue state: paused flag, weight and concurrency limit
+		api.GET("/queues", func(c *gin.Context) {
+			queues, err := repo.ListQueues(c.Request.Context())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			if queues == nil {
+				queues = []*models.Queue{}
+			}
+
+			c.JSON(http.StatusOK, queues)
+		})
+
+		// Health check
+		api.GET("/health", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+		})
+
+		// Job statistics endpoint
+		api.GET("/stats", func(c *gin.Context) {
+			stats, err := repo.GetJobStats(c.Request.Context())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			queues, err := repo.ListQueues(c.Request.Context())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			// Calculate total jobs
+			totalJobs := 0
+			for _, count := range stats {
+				totalJobs += count
+			}
+
+			pausedQueues := []string{}
+			for _, queue := range queues {
+				if queue.Paused {
+					pausedQueues = append(pausedQueues, queue.Name)
+				}
+			}
+
+			c.JSON(http.StatusOK, gin.H{
+				"total_jobs":      totalJobs,
+				"pending_jobs":    stats["pending"],
+				"processing_jobs": stats["processing"],
+				"completed_jobs":  stats["completed"],
+				"dead_jobs":       stats["dead"],
+				"cancelled_jobs":  stats["cancelled"],
+				"paused_queues":   pausedQueues,
+			})
+		})
+
+		// WebSocket endpoint
+		api.GET("/ws", func(c *gin.Context) {
+			wsServer.HandleWebSocket(c.Writer, c.Request)
+		})
+
+		// Metrics endpoint
+		api.GET("/metrics", func(c *gin.Context) {
+			// TODO: Implement Prometheus metrics endpoint
+			c.JSON(http.StatusOK, gin.H{"status": "metrics not implemented"})
+		})
+	}
+
+	return router
+}
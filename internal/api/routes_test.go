@@ -0,0 +1,445 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arthures11/gosynq/internal/config"
+	"github.com/arthures11/gosynq/internal/dispatcher"
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/repository"
+)
+
+type testAPI struct {
+	router http.Handler
+	repo   *repository.MemoryRepository
+}
+
+// newTestAPI serves the API from an in-memory store with a dispatcher that
+// is never started, so enqueued jobs stay where the handlers put them.
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewMemoryRepository()
+	disp := dispatcher.NewDispatcher(repo, dispatcher.DispatcherConfig{})
+	auth := config.AdminConfig{Username: "admin", Password: "secret"}
+	return &testAPI{
+		router: NewRouter(disp, repo, nil, auth),
+		repo:   repo,
+	}
+}
+
+// do sends a request with an optional body and decodes the JSON answer
+// into out, returning the status code.
+func (a *testAPI) do(t *testing.T, method, path, contentType, body string, out any) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return a.send(t, req, out)
+}
+
+// admin is do for a JSON request with the admin credentials.
+func (a *testAPI) admin(t *testing.T, method, path, body string, out any) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth("admin", "secret")
+	return a.send(t, req, out)
+}
+
+func (a *testAPI) send(t *testing.T, req *http.Request, out any) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s answered %d %q: %v", req.Method, req.URL.Path, rec.Code, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func (a *testAPI) enqueue(t *testing.T, body string) (int, map[string]any) {
+	t.Helper()
+
+	var resp map[string]any
+	code := a.do(t, http.MethodPost, "/api/v1/jobs", "application/json", body, &resp)
+	return code, resp
+}
+
+func TestResolveRunAt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		runAt   time.Time
+		runIn   string
+		want    time.Time
+		wantErr string
+	}{
+		{name: "neither runs now", want: now},
+		{name: "run_in", runIn: "90s", want: now.Add(90 * time.Second)},
+		{name: "run_in hours", runIn: "2h30m", want: now.Add(150 * time.Minute)},
+		{name: "zero run_in runs now", runIn: "0s", want: now},
+		{name: "run_at", runAt: now.Add(time.Hour), want: now.Add(time.Hour)},
+		{name: "past run_at runs now", runAt: now.Add(-time.Hour), want: now},
+		{name: "both", runAt: now.Add(time.Hour), runIn: "1h", wantErr: "mutually exclusive"},
+		{name: "negative run_in", runIn: "-5m", wantErr: "must not be negative"},
+		{name: "unparsable run_in", runIn: "soon", wantErr: "invalid run_in"},
+		{name: "bare number run_in", runIn: "30", wantErr: "invalid run_in"},
+		{name: "run_in beyond a year", runIn: "9000h", wantErr: "within a year"},
+		{name: "run_at beyond a year", runAt: now.Add(maxRunDelay + time.Hour), wantErr: "within a year"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveRunAt(tt.runAt, tt.runIn, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveRunAt() = %v, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveRunAt() = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("resolveRunAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnqueueRunIn(t *testing.T) {
+	a := newTestAPI(t)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantStatus string
+	}{
+		{"now", `{"queue":"email","payload":{}}`, http.StatusCreated, "queued"},
+		{"run_in", `{"queue":"email","payload":{},"run_in":"10m"}`, http.StatusCreated, "scheduled"},
+		{"run_at", `{"queue":"email","payload":{},"run_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`, http.StatusCreated, "scheduled"},
+		{"both", `{"queue":"email","payload":{},"run_in":"10m","run_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`, http.StatusBadRequest, ""},
+		{"negative", `{"queue":"email","payload":{},"run_in":"-10m"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := a.enqueue(t, tt.body)
+			if code != tt.wantCode {
+				t.Fatalf("POST /jobs = %d %v, want %d", code, resp, tt.wantCode)
+			}
+			if tt.wantStatus != "" && resp["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", resp["status"], tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestListScheduledJobs(t *testing.T) {
+	a := newTestAPI(t)
+
+	for _, body := range []string{
+		`{"queue":"email","payload":{},"run_in":"2h"}`,
+		`{"queue":"email","payload":{}}`,
+		`{"queue":"email","payload":{},"run_in":"1h"}`,
+		`{"queue":"reports","payload":{},"run_in":"3h"}`,
+	} {
+		if code, resp := a.enqueue(t, body); code != http.StatusCreated {
+			t.Fatalf("POST /jobs = %d %v", code, resp)
+		}
+	}
+
+	var jobs []*models.Job
+	if code := a.do(t, http.MethodGet, "/api/v1/jobs?status=scheduled", "", "", &jobs); code != http.StatusOK {
+		t.Fatalf("GET /jobs?status=scheduled = %d", code)
+	}
+	if len(jobs) != 3 {
+		t.Fatalf("scheduled view has %d jobs, want 3", len(jobs))
+	}
+	for i, job := range jobs {
+		if job.Status != models.StatusPending || !job.RunAt.After(time.Now()) {
+			t.Errorf("job %d is %s at %v, want pending in the future", i, job.Status, job.RunAt)
+		}
+		if i > 0 && job.RunAt.Before(jobs[i-1].RunAt) {
+			t.Errorf("job %d runs before job %d, want soonest first", i, i-1)
+		}
+	}
+
+	if code := a.do(t, http.MethodGet, "/api/v1/jobs?status=scheduled&queue=reports", "", "", &jobs); code != http.StatusOK {
+		t.Fatalf("GET /jobs?status=scheduled&queue=reports = %d", code)
+	}
+	if len(jobs) != 1 || jobs[0].Queue != "reports" {
+		t.Errorf("scheduled view of reports has %d jobs, want 1", len(jobs))
+	}
+}
+
+type batchResponse struct {
+	Enqueued   int           `json:"enqueued"`
+	Duplicates int           `json:"duplicates"`
+	Invalid    int           `json:"invalid"`
+	Results    []batchResult `json:"results"`
+}
+
+func (a *testAPI) enqueueBatch(t *testing.T, contentType, body string) (int, batchResponse) {
+	t.Helper()
+
+	var resp batchResponse
+	code := a.do(t, http.MethodPost, "/api/v1/jobs/batch", contentType, body, &resp)
+	return code, resp
+}
+
+func resultStatuses(results []batchResult) []string {
+	statuses := make([]string, len(results))
+	for i, result := range results {
+		statuses[i] = result.Status
+	}
+	return statuses
+}
+
+func (a *testAPI) countJobs(t *testing.T) int {
+	t.Helper()
+
+	jobs, err := a.repo.ListJobs(context.Background(), repository.JobFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(jobs)
+}
+
+func TestBatchEnqueueFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json array", "application/json", `[
+			{"queue":"email","payload":{"to":"a@example.com"}},
+			{"queue":"email","payload":{"to":"b@example.com"},"run_in":"1h"}
+		]`},
+		{"ndjson", "application/x-ndjson", `{"queue":"email","payload":{"to":"a@example.com"}}
+
+{"queue":"email","payload":{"to":"b@example.com"},"run_in":"1h"}
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAPI(t)
+
+			code, resp := a.enqueueBatch(t, tt.contentType, tt.body)
+			if code != http.StatusOK {
+				t.Fatalf("POST /jobs/batch = %d %+v", code, resp)
+			}
+			if got, want := resultStatuses(resp.Results), []string{"queued", "scheduled"}; !slices.Equal(got, want) {
+				t.Errorf("results = %v, want %v", got, want)
+			}
+			if resp.Enqueued != 2 || a.countJobs(t) != 2 {
+				t.Errorf("enqueued %d and stored %d jobs, want 2", resp.Enqueued, a.countJobs(t))
+			}
+			for i, result := range resp.Results {
+				if result.Index != i || result.JobID == "" || result.RunAt == nil {
+					t.Errorf("result %d = %+v, want its index, job ID and run_at", i, result)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchEnqueuePartialFailure(t *testing.T) {
+	a := newTestAPI(t)
+
+	code, resp := a.enqueueBatch(t, "application/x-ndjson", `{"queue":"email","payload":{}}
+{"queue":"email"}
+not json
+{"queue":"email","payload":{},"run_in":"-1m"}
+{"queue":"email","payload":{},"retry":{"strategy":"random"}}
+{"queue":"reports","payload":{}}
+`)
+	if code != http.StatusOK {
+		t.Fatalf("POST /jobs/batch = %d %+v", code, resp)
+	}
+
+	want := []string{"queued", "invalid", "invalid", "invalid", "invalid", "queued"}
+	if got := resultStatuses(resp.Results); !slices.Equal(got, want) {
+		t.Fatalf("results = %v, want %v", got, want)
+	}
+	for _, i := range []int{1, 2, 3, 4} {
+		if result := resp.Results[i]; result.Error == "" || result.JobID != "" {
+			t.Errorf("invalid result %d = %+v, want an error and no job", i, result)
+		}
+	}
+	if resp.Enqueued != 2 || resp.Invalid != 4 || a.countJobs(t) != 2 {
+		t.Errorf("enqueued %d, invalid %d, stored %d, want 2, 4 and 2", resp.Enqueued, resp.Invalid, a.countJobs(t))
+	}
+}
+
+func TestBatchEnqueueDuplicateKeys(t *testing.T) {
+	a := newTestAPI(t)
+
+	code, first := a.enqueue(t, `{"queue":"email","payload":{},"idempotency_key":"held"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("POST /jobs = %d %v", code, first)
+	}
+
+	code, resp := a.enqueueBatch(t, "application/json", `[
+		{"queue":"email","payload":{},"idempotency_key":"welcome-1"},
+		{"queue":"email","payload":{},"idempotency_key":"welcome-1"},
+		{"queue":"reports","payload":{},"idempotency_key":"welcome-1"},
+		{"queue":"email","payload":{},"idempotency_key":"held"}
+	]`)
+	if code != http.StatusOK {
+		t.Fatalf("POST /jobs/batch = %d %+v", code, resp)
+	}
+
+	want := []string{"queued", "duplicate", "queued", "duplicate"}
+	if got := resultStatuses(resp.Results); !slices.Equal(got, want) {
+		t.Fatalf("results = %v, want %v", got, want)
+	}
+	if resp.Results[1].JobID != resp.Results[0].JobID {
+		t.Errorf("duplicate in the batch points at %s, want the earlier item's job %s", resp.Results[1].JobID, resp.Results[0].JobID)
+	}
+	if resp.Results[3].JobID != first["job_id"] {
+		t.Errorf("duplicate of a stored job points at %s, want %v", resp.Results[3].JobID, first["job_id"])
+	}
+	if resp.Enqueued != 2 || resp.Duplicates != 2 || a.countJobs(t) != 3 {
+		t.Errorf("enqueued %d, duplicates %d, stored %d, want 2, 2 and 3", resp.Enqueued, resp.Duplicates, a.countJobs(t))
+	}
+}
+
+func TestBatchEnqueueRejectsBadBodies(t *testing.T) {
+	a := newTestAPI(t)
+
+	for name, body := range map[string]string{
+		"empty array": `[]`,
+		"object":      `{"queue":"email","payload":{}}`,
+		"truncated":   `[{"queue":"email","payload":{}}`,
+	} {
+		var resp map[string]any
+		if code := a.do(t, http.MethodPost, "/api/v1/jobs/batch", "application/json", body, &resp); code != http.StatusBadRequest {
+			t.Errorf("%s: POST /jobs/batch = %d %v, want 400", name, code, resp)
+		}
+	}
+	if n := a.countJobs(t); n != 0 {
+		t.Errorf("store holds %d jobs after rejected batches", n)
+	}
+}
+
+func TestRetryRequeuesDeadJob(t *testing.T) {
+	a := newTestAPI(t)
+	ctx := context.Background()
+
+	code, resp := a.enqueue(t, `{"queue":"email","payload":{},"max_retries":1}`)
+	if code != http.StatusCreated {
+		t.Fatalf("POST /jobs = %d %v", code, resp)
+	}
+	id := resp["job_id"].(string)
+
+	// Run the job into the dead-letter queue
+	for range 2 {
+		jobs, err := a.repo.PickJobs(ctx, "worker-1", nil, 1, time.Minute)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("PickJobs() = %v, %v", jobs, err)
+		}
+		if err := a.repo.UpdateJobForRetry(ctx, id, jobs[0].LeaseToken, time.Now().Add(-time.Second), "boom"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs, err := a.repo.PickJobs(ctx, "worker-1", nil, 1, time.Minute)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("PickJobs() = %v, %v", jobs, err)
+	}
+	if err := a.repo.SnoozeJob(ctx, id, jobs[0].LeaseToken, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	jobs, err = a.repo.PickJobs(ctx, "worker-1", nil, 1, time.Minute)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("PickJobs() = %v, %v", jobs, err)
+	}
+	if err := a.repo.FinishJob(ctx, id, jobs[0].LeaseToken, models.StatusDead, "boom"); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/api/v1/admin/jobs/" + id + "/retry"
+	if code := a.do(t, http.MethodPost, path, "", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("retry without credentials = %d, want 401", code)
+	}
+	if code := a.admin(t, http.MethodPost, path, "", nil); code != http.StatusOK {
+		t.Fatalf("retry = %d, want 200", code)
+	}
+
+	job, err := a.repo.GetJobByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.StatusPending || job.Attempts != 0 || job.Snoozes != 0 || job.LastError != "" ||
+		job.LockedBy != "" || job.LeaseExpiresAt != nil || job.RunAt.After(time.Now()) {
+		t.Errorf("retried job = %+v, want a pending job due now with its attempts, error and lease cleared", job)
+	}
+
+	// Only dead jobs can be retried
+	if code := a.admin(t, http.MethodPost, path, "", nil); code != http.StatusBadRequest {
+		t.Errorf("retry of a pending job = %d, want 400", code)
+	}
+	if code := a.admin(t, http.MethodPost, "/api/v1/admin/jobs/missing/retry", "", nil); code != http.StatusNotFound {
+		t.Errorf("retry of a missing job = %d, want 404", code)
+	}
+}
+
+func TestScheduleWritesRequireAdmin(t *testing.T) {
+	a := newTestAPI(t)
+	body := `{"name":"nightly","cron":"@daily","queue":"reports","payload":{}}`
+
+	var schedule models.Schedule
+	if code := a.do(t, http.MethodPost, "/api/v1/schedules", "application/json", body, nil); code != http.StatusUnauthorized {
+		t.Errorf("POST /schedules without credentials = %d, want 401", code)
+	}
+	if code := a.admin(t, http.MethodPost, "/api/v1/schedules", body, &schedule); code != http.StatusCreated {
+		t.Fatalf("POST /schedules = %d, want 201", code)
+	}
+	path := "/api/v1/schedules/" + schedule.ID
+
+	var schedules []*models.Schedule
+	if code := a.do(t, http.MethodGet, "/api/v1/schedules", "", "", &schedules); code != http.StatusOK || len(schedules) != 1 {
+		t.Errorf("GET /schedules = %d with %d schedules, want 200 with 1", code, len(schedules))
+	}
+	if code := a.do(t, http.MethodGet, path, "", "", &schedule); code != http.StatusOK {
+		t.Errorf("GET /schedules/:id = %d, want 200", code)
+	}
+
+	update := `{"name":"hourly","cron":"@hourly","queue":"reports","payload":{}}`
+	if code := a.do(t, http.MethodPut, path, "application/json", update, nil); code != http.StatusUnauthorized {
+		t.Errorf("PUT /schedules/:id without credentials = %d, want 401", code)
+	}
+	if code := a.admin(t, http.MethodPut, path, update, &schedule); code != http.StatusOK || schedule.Cron != "@hourly" {
+		t.Errorf("PUT /schedules/:id = %d with cron %q, want 200 with @hourly", code, schedule.Cron)
+	}
+
+	if code := a.do(t, http.MethodDelete, path, "", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("DELETE /schedules/:id without credentials = %d, want 401", code)
+	}
+	if code := a.admin(t, http.MethodDelete, path, "", nil); code != http.StatusOK {
+		t.Errorf("DELETE /schedules/:id = %d, want 200", code)
+	}
+	if code := a.do(t, http.MethodGet, path, "", "", nil); code != http.StatusNotFound {
+		t.Errorf("GET /schedules/:id after delete = %d, want 404", code)
+	}
+}
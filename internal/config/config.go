@@ -1,69 +1,213 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Worker   WorkerConfig
-	Retries  RetryConfig
+	Server      ServerConfig           `yaml:"server"`
+	Database    DatabaseConfig         `yaml:"database"`
+	Worker      WorkerConfig           `yaml:"worker"`
+	Retries     RetryConfig            `yaml:"retries"`
+	Queues      map[string]QueueConfig `yaml:"queues"`
+	Scheduler   SchedulerConfig        `yaml:"scheduler"`
+	Idempotency IdempotencyConfig      `yaml:"idempotency"`
+	Admin       AdminConfig            `yaml:"admin"`
 }
 
 type ServerConfig struct {
-	Port        int
-	Host        string
-	MetricsPort int
+	Port int `yaml:"port"`
+	// Host is the address the HTTP server binds to. Empty means every
+	// interface.
+	Host string `yaml:"host"`
+	// APIOnly serves the HTTP API without starting workers, the scheduler
+	// or the reaper, leaving those to separate worker nodes.
+	APIOnly bool `yaml:"api_only"`
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	// Driver selects the storage backend: "postgres" or "sqlite".
+	Driver   string `yaml:"driver"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBName   string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// Path is the database file used by the sqlite driver.
+	Path string `yaml:"path"`
 }
 
 type WorkerConfig struct {
-	PoolSize          int
-	VisibilityTimeout time.Duration
-	Concurrency       int
+	PoolSize          int           `yaml:"pool_size"`
+	VisibilityTimeout time.Duration `yaml:"visibility_timeout"`
+	// Queues this node's workers pick from. Empty means all queues.
+	Queues []string `yaml:"queues"`
+	// HeartbeatInterval is how often a running job's lease is extended.
+	// It should be well below VisibilityTimeout; zero disables heartbeats.
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	// ReaperInterval is how often jobs whose worker let the visibility
+	// timeout lapse are returned to pending.
+	ReaperInterval time.Duration `yaml:"reaper_interval"`
+	// PollInterval is the longest a node with free workers goes without
+	// looking for jobs. On PostgreSQL, nodes are woken by notifications
+	// instead and this only bounds how late a job with a future run_at
+	// starts.
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+// QueueConfig sets a queue's share of pickups relative to other queues and
+// its cluster-wide concurrency limit. A zero MaxInFlight means unlimited.
+type QueueConfig struct {
+	Weight      int `yaml:"weight"`
+	MaxInFlight int `yaml:"max_in_flight"`
+	// Retry, if set, is the queue's retry policy.
+	Retry *models.RetryPolicy `yaml:"retry"`
+}
+
+type SchedulerConfig struct {
+	// PollInterval is how often each node checks for due cron schedules.
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+type IdempotencyConfig struct {
+	// Retention is how long an idempotency key keeps rejecting duplicates
+	// in its queue. Zero keeps keys for the lifetime of the job.
+	Retention time.Duration `yaml:"retention"`
 }
 
 type RetryConfig struct {
-	DefaultStrategy string
-	DefaultInterval int
-	MaxAttempts     int
-	ExponentialBase float64
+	// DefaultStrategy is fixed, linear or exponential.
+	DefaultStrategy string `yaml:"default_strategy"`
+	// DefaultInterval is the delay before the first retry, in seconds.
+	DefaultInterval int `yaml:"default_interval"`
+	// MaxAttempts caps how many times any job runs, whatever its
+	// max_retries. Zero means no cap.
+	MaxAttempts     int     `yaml:"max_attempts"`
+	ExponentialBase float64 `yaml:"exponential_base"`
+	// MaxDelay caps the delay between retries. Zero means no cap.
+	MaxDelay time.Duration `yaml:"max_delay"`
+	// Jitter moves each retry delay randomly by up to this fraction of it.
+	Jitter float64 `yaml:"jitter"`
+}
+
+// AdminConfig holds the basic auth credentials for the admin API. There
+// are none by default, which leaves the admin API disabled.
+type AdminConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
 }
 
 func NewDefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:        8080,
-			Host:        "localhost",
-			MetricsPort: 9090,
+			Port: 8080,
 		},
 		Database: DatabaseConfig{
+			Driver:   "postgres",
 			Host:     "localhost",
 			Port:     5432,
 			User:     "postgres",
-			Password: "12345",
+			Password: "postgres",
 			DBName:   "gosynq_db",
 			SSLMode:  "disable",
+			Path:     "gosynq.db",
 		},
 		Worker: WorkerConfig{
 			PoolSize:          10,
 			VisibilityTimeout: 30 * time.Second,
-			Concurrency:       5,
+			HeartbeatInterval: 10 * time.Second,
+			ReaperInterval:    10 * time.Second,
+			PollInterval:      time.Second,
 		},
 		Retries: RetryConfig{
 			DefaultStrategy: "exponential",
 			DefaultInterval: 5,
 			MaxAttempts:     5,
 			ExponentialBase: 2.0,
+			MaxDelay:        time.Hour,
+		},
+		Scheduler: SchedulerConfig{
+			PollInterval: time.Second,
 		},
 	}
 }
+
+// Clone returns a deep copy of cfg, so changes to it do not reach cfg.
+func (cfg *Config) Clone() *Config {
+	clone := *cfg
+	clone.Worker.Queues = slices.Clone(cfg.Worker.Queues)
+	if cfg.Queues != nil {
+		clone.Queues = make(map[string]QueueConfig, len(cfg.Queues))
+		for name, queue := range cfg.Queues {
+			if queue.Retry != nil {
+				retry := *queue.Retry
+				queue.Retry = &retry
+			}
+			clone.Queues[name] = queue
+		}
+	}
+	return &clone
+}
+
+// Validate reports every out-of-range setting in cfg at once.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(cfg.Server.Port > 0 && cfg.Server.Port <= 65535, "server.port must be between 1 and 65535")
+
+	switch cfg.Database.Driver {
+	case "", "postgres":
+		check(cfg.Database.Host != "", "database.host is required")
+		check(cfg.Database.Port > 0 && cfg.Database.Port <= 65535, "database.port must be between 1 and 65535")
+		check(cfg.Database.DBName != "", "database.name is required")
+	case "sqlite":
+		check(cfg.Database.Path != "", "database.path is required for sqlite")
+	default:
+		errs = append(errs, fmt.Errorf("database.driver must be postgres or sqlite, not %q", cfg.Database.Driver))
+	}
+
+	check(cfg.Worker.PoolSize > 0, "worker.pool_size must be at least 1")
+	check(cfg.Worker.VisibilityTimeout > 0, "worker.visibility_timeout must be positive")
+	check(cfg.Worker.HeartbeatInterval >= 0 && cfg.Worker.HeartbeatInterval < cfg.Worker.VisibilityTimeout,
+		"worker.heartbeat_interval must be below worker.visibility_timeout")
+	check(cfg.Worker.ReaperInterval >= 0, "worker.reaper_interval must not be negative")
+	check(cfg.Worker.PollInterval >= 0, "worker.poll_interval must not be negative")
+
+	defaultPolicy := models.RetryPolicy{
+		Strategy: cfg.Retries.DefaultStrategy,
+		Base:     cfg.Retries.ExponentialBase,
+		MaxDelay: models.Duration(cfg.Retries.MaxDelay),
+		Jitter:   cfg.Retries.Jitter,
+	}
+	if err := defaultPolicy.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("retries: %w", err))
+	}
+	check(cfg.Retries.DefaultInterval >= 0, "retries.default_interval must not be negative")
+	check(cfg.Retries.MaxAttempts >= 0, "retries.max_attempts must not be negative")
+
+	for name, queue := range cfg.Queues {
+		check(queue.Weight >= 0 && queue.MaxInFlight >= 0, "queues.%s: weight and max_in_flight must not be negative", name)
+		if queue.Retry != nil {
+			if err := queue.Retry.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("queues.%s.retry: %w", name, err))
+			}
+		}
+	}
+
+	check(cfg.Scheduler.PollInterval >= 0, "scheduler.poll_interval must not be negative")
+	check(cfg.Idempotency.Retention >= 0, "idempotency.retention must not be negative")
+	check((cfg.Admin.Username == "") == (cfg.Admin.Password == ""), "admin.username and admin.password must be set together")
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	if err := NewDefaultConfig().Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   string
+	}{
+		{"port out of range", func(c *Config) { c.Server.Port = 70000 }, "server.port"},
+		{"postgres without host", func(c *Config) { c.Database.Host = "" }, "database.host"},
+		{"sqlite without path", func(c *Config) { c.Database.Driver, c.Database.Path = "sqlite", "" }, "database.path"},
+		{"unknown driver", func(c *Config) { c.Database.Driver = "mysql" }, "database.driver"},
+		{"no workers", func(c *Config) { c.Worker.PoolSize = 0 }, "worker.pool_size"},
+		{"heartbeat above visibility timeout", func(c *Config) { c.Worker.HeartbeatInterval = time.Hour }, "worker.heartbeat_interval"},
+		{"unknown retry strategy", func(c *Config) { c.Retries.DefaultStrategy = "random" }, "retries"},
+		{"bad queue policy", func(c *Config) {
+			c.Queues = map[string]QueueConfig{"email": {Retry: &models.RetryPolicy{Jitter: 2}}}
+		}, "queues.email.retry"},
+		{"negative retention", func(c *Config) { c.Idempotency.Retention = -time.Second }, "idempotency.retention"},
+		{"admin user without password", func(c *Config) { c.Admin.Username = "admin" }, "admin.username"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Server.Port = 0
+	cfg.Worker.PoolSize = 0
+	cfg.Scheduler.PollInterval = -time.Second
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	for _, want := range []string{"server.port", "worker.pool_size", "scheduler.poll_interval"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, missing %s", err, want)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Worker.Queues = []string{"email"}
+	cfg.Queues = map[string]QueueConfig{"email": {Weight: 2, Retry: &models.RetryPolicy{Jitter: 0.1}}}
+
+	clone := cfg.Clone()
+	clone.Worker.Queues[0] = "reports"
+	clone.Queues["email"].Retry.Jitter = 0.5
+	clone.Queues["reports"] = QueueConfig{}
+
+	if cfg.Worker.Queues[0] != "email" {
+		t.Errorf("Worker.Queues changed to %v", cfg.Worker.Queues)
+	}
+	if cfg.Queues["email"].Retry.Jitter != 0.1 {
+		t.Errorf("queue retry policy changed to %+v", cfg.Queues["email"].Retry)
+	}
+	if _, ok := cfg.Queues["reports"]; ok {
+		t.Error("queue added to the original")
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a configuration value that can be set from the environment
+// and the command line as well as from the config file. key is its path in
+// the file; the variable is GOSYNQ_ followed by the key in upper case with
+// dots as underscores, and the flag is the key with dashes.
+type setting struct {
+	key    string
+	usage  string
+	secret bool
+	field  func(cfg *Config) any
+}
+
+var settings = []setting{
+	{key: "server.host", usage: "address to listen on, empty for every interface", field: func(c *Config) any { return &c.Server.Host }},
+	{key: "server.port", usage: "HTTP port", field: func(c *Config) any { return &c.Server.Port }},
+	{key: "server.api_only", usage: "serve the API without running workers, the scheduler or the reaper", field: func(c *Config) any { return &c.Server.APIOnly }},
+
+	{key: "database.driver", usage: "storage backend: postgres or sqlite", field: func(c *Config) any { return &c.Database.Driver }},
+	{key: "database.host", usage: "PostgreSQL host", field: func(c *Config) any { return &c.Database.Host }},
+	{key: "database.port", usage: "PostgreSQL port", field: func(c *Config) any { return &c.Database.Port }},
+	{key: "database.user", usage: "PostgreSQL user", field: func(c *Config) any { return &c.Database.User }},
+	{key: "database.password", usage: "PostgreSQL password", secret: true, field: func(c *Config) any { return &c.Database.Password }},
+	{key: "database.name", usage: "PostgreSQL database name", field: func(c *Config) any { return &c.Database.DBName }},
+	{key: "database.sslmode", usage: "PostgreSQL sslmode", field: func(c *Config) any { return &c.Database.SSLMode }},
+	{key: "database.path", usage: "SQLite database file", field: func(c *Config) any { return &c.Database.Path }},
+
+	{key: "worker.pool_size", usage: "number of workers on this node", field: func(c *Config) any { return &c.Worker.PoolSize }},
+	{key: "worker.queues", usage: "comma-separated `queues` to work on, empty for all", field: func(c *Config) any { return &c.Worker.Queues }},
+	{key: "worker.visibility_timeout", usage: "lease length of a running job", field: func(c *Config) any { return &c.Worker.VisibilityTimeout }},
+	{key: "worker.heartbeat_interval", usage: "how often running jobs extend their lease", field: func(c *Config) any { return &c.Worker.HeartbeatInterval }},
+	{key: "worker.reaper_interval", usage: "how often expired leases are reclaimed", field: func(c *Config) any { return &c.Worker.ReaperInterval }},
+	{key: "worker.poll_interval", usage: "longest wait between looks for new jobs", field: func(c *Config) any { return &c.Worker.PollInterval }},
+
+	{key: "retries.default_strategy", usage: "retry backoff: fixed, linear or exponential", field: func(c *Config) any { return &c.Retries.DefaultStrategy }},
+	{key: "retries.default_interval", usage: "delay before the first retry, in seconds", field: func(c *Config) any { return &c.Retries.DefaultInterval }},
+	{key: "retries.max_attempts", usage: "most runs of any job, 0 for no cap", field: func(c *Config) any { return &c.Retries.MaxAttempts }},
+	{key: "retries.exponential_base", usage: "growth factor of exponential backoff", field: func(c *Config) any { return &c.Retries.ExponentialBase }},
+	{key: "retries.max_delay", usage: "longest delay between retries, 0 for no cap", field: func(c *Config) any { return &c.Retries.MaxDelay }},
+	{key: "retries.jitter", usage: "random spread of retry delays, from 0 to 1", field: func(c *Config) any { return &c.Retries.Jitter }},
+
+	{key: "scheduler.poll_interval", usage: "how often due cron schedules are checked", field: func(c *Config) any { return &c.Scheduler.PollInterval }},
+	{key: "idempotency.retention", usage: "how long idempotency keys are held, 0 for the job's lifetime", field: func(c *Config) any { return &c.Idempotency.Retention }},
+
+	{key: "admin.username", usage: "admin API user, empty to disable the admin API", field: func(c *Config) any { return &c.Admin.Username }},
+	{key: "admin.password", usage: "admin API password", secret: true, field: func(c *Config) any { return &c.Admin.Password }},
+}
+
+func (s setting) env() string {
+	return "GOSYNQ_" + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+func (s setting) flag() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// Load builds the configuration in layers: the defaults, then the YAML file
+// named by the -config flag or GOSYNQ_CONFIG, then GOSYNQ_* environment
+// variables, then the remaining flags. Callers may define flags of their
+// own on fs before calling Load. The result is validated.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := NewDefaultConfig()
+
+	// Flags are parsed into a copy of the defaults and only the ones given
+	// are copied over once the file and environment have been applied
+	flagged := NewDefaultConfig()
+	configPath := fs.String("config", os.Getenv("GOSYNQ_CONFIG"), "YAML config `file`")
+	for _, s := range settings {
+		usage := s.usage + " (" + s.env() + ")"
+		switch p := s.field(flagged).(type) {
+		case *string:
+			def := *p
+			if s.secret {
+				def = ""
+			}
+			fs.StringVar(p, s.flag(), def, usage)
+		case *bool:
+			fs.BoolVar(p, s.flag(), *p, usage)
+		case *int:
+			fs.IntVar(p, s.flag(), *p, usage)
+		case *float64:
+			fs.Float64Var(p, s.flag(), *p, usage)
+		case *time.Duration:
+			fs.DurationVar(p, s.flag(), *p, usage)
+		case *[]string:
+			fs.Func(s.flag(), usage, func(raw string) error {
+				return parse(p, raw)
+			})
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(cfg, *configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		raw, ok := os.LookupEnv(s.env())
+		if !ok || raw == "" {
+			continue
+		}
+		if err := parse(s.field(cfg), raw); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", s.env(), err)
+		}
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for _, s := range settings {
+		if set[s.flag()] {
+			copyValue(s.field(cfg), s.field(flagged))
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile overlays the YAML file at path on cfg. Unknown keys are errors,
+// so a misspelt setting is not silently ignored.
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// parse sets the value field points to from its text form.
+func parse(field any, raw string) error {
+	switch p := field.(type) {
+	case *string:
+		*p = raw
+	case *bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		*p = v
+	case *int:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		*p = v
+	case *float64:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		*p = v
+	case *time.Duration:
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as \"30s\"", raw)
+		}
+		*p = v
+	case *[]string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*p = list
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+	return nil
+}
+
+// copyValue sets the value dst points to from the one src points to.
+func copyValue(dst, src any) {
+	switch p := dst.(type) {
+	case *string:
+		*p = *src.(*string)
+	case *bool:
+		*p = *src.(*bool)
+	case *int:
+		*p = *src.(*int)
+	case *float64:
+		*p = *src.(*float64)
+	case *time.Duration:
+		*p = *src.(*time.Duration)
+	case *[]string:
+		*p = *src.(*[]string)
+	}
+}
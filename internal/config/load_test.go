@@ -0,0 +1,160 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// load runs Load on a fresh flag set with no GOSYNQ_* variables set other
+// than those in env.
+func load(t *testing.T, env map[string]string, args ...string) (*Config, error) {
+	t.Helper()
+
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, "GOSYNQ_") {
+			t.Setenv(name, "")
+		}
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return Load(fs, args)
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(t, nil)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if want := NewDefaultConfig(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load() = %+v, want the defaults %+v", cfg, want)
+	}
+}
+
+// The example file documents the defaults, so loading it must change
+// nothing.
+func TestExampleConfigIsTheDefaults(t *testing.T) {
+	cfg, err := load(t, nil, "-config", "../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	// "queues: []" decodes to an empty list rather than nil
+	if len(cfg.Worker.Queues) == 0 {
+		cfg.Worker.Queues = nil
+	}
+	if want := NewDefaultConfig(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load() = %+v, want the defaults %+v", cfg, want)
+	}
+}
+
+func TestLoadLayering(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  port: 9000
+database:
+  driver: sqlite
+  path: jobs.db
+worker:
+  pool_size: 7
+  queues: [email, reports]
+  visibility_timeout: 2m
+`)
+
+	cfg, err := load(t, map[string]string{
+		"GOSYNQ_WORKER_POOL_SIZE":          "8",
+		"GOSYNQ_WORKER_VISIBILITY_TIMEOUT": "45s",
+	}, "-config", path, "-worker-visibility-timeout", "1m", "-server-api-only")
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+
+	if cfg.Server.Port != 9000 {
+		t.Errorf("server.port = %d, want 9000 from the file", cfg.Server.Port)
+	}
+	if cfg.Database.Driver != "sqlite" || cfg.Database.Path != "jobs.db" {
+		t.Errorf("database = %+v, want sqlite at jobs.db from the file", cfg.Database)
+	}
+	if cfg.Worker.PoolSize != 8 {
+		t.Errorf("worker.pool_size = %d, want 8 from the environment", cfg.Worker.PoolSize)
+	}
+	if cfg.Worker.VisibilityTimeout != time.Minute {
+		t.Errorf("worker.visibility_timeout = %v, want 1m from the flag", cfg.Worker.VisibilityTimeout)
+	}
+	if !cfg.Server.APIOnly {
+		t.Error("server.api_only = false, want true from the flag")
+	}
+	if want := []string{"email", "reports"}; !reflect.DeepEqual(cfg.Worker.Queues, want) {
+		t.Errorf("worker.queues = %v, want %v", cfg.Worker.Queues, want)
+	}
+	if cfg.Worker.HeartbeatInterval != NewDefaultConfig().Worker.HeartbeatInterval {
+		t.Errorf("worker.heartbeat_interval = %v, want the default", cfg.Worker.HeartbeatInterval)
+	}
+}
+
+func TestLoadConfigFromEnvironment(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 9100\n")
+
+	cfg, err := load(t, map[string]string{
+		"GOSYNQ_CONFIG":        path,
+		"GOSYNQ_WORKER_QUEUES": "email, reports,",
+	})
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+
+	if cfg.Server.Port != 9100 {
+		t.Errorf("server.port = %d, want 9100", cfg.Server.Port)
+	}
+	if want := []string{"email", "reports"}; !reflect.DeepEqual(cfg.Worker.Queues, want) {
+		t.Errorf("worker.queues = %v, want %v", cfg.Worker.Queues, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{name: "unknown key", file: "worker:\n  poolsize: 3\n", want: "poolsize"},
+		{name: "bad environment value", env: map[string]string{"GOSYNQ_SERVER_PORT": "http"}, want: "GOSYNQ_SERVER_PORT"},
+		{name: "bad flag value", args: []string{"-worker-pool-size", "many"}, want: "worker-pool-size"},
+		{name: "invalid result", env: map[string]string{"GOSYNQ_WORKER_POOL_SIZE": "0"}, want: "worker.pool_size"},
+		{name: "missing file", args: []string{"-config", "/nonexistent/gosynq.yaml"}, want: "config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeConfig(t, tt.file)}, args...)
+			}
+
+			_, err := load(t, tt.env, args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package demojobs holds the stand-in handler the bundled server and worker
+// binaries run for jobs from the demo generator.
+package demojobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/arthures11/gosynq"
+)
+
+// Options routes the queues the demo generator enqueues into to Simulate.
+func Options() []gosynq.Option {
+	var opts []gosynq.Option
+	for _, queue := range []string{"default", "high", "low"} {
+		opts = append(opts, gosynq.WithHandler(queue, Simulate))
+	}
+	return opts
+}
+
+// Simulate stands in for real work when running the demo.
+func Simulate(ctx context.Context, job *gosynq.Job) error {
+	log.Printf("Simulating job %s from queue %s", job.ID, job.Queue)
+
+	// Simulate work
+	time.Sleep(1 * time.Second)
+
+	// Simulate random failures for demo purposes
+	if rand.Float32() < 0.2 { // 20% chance of failure
+		return fmt.Errorf("simulated processing error")
+	}
+
+	return nil
+}
@@ -2,43 +2,106 @@ package dispatcher
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math/rand/v2"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/arthures11/gosynq/internal/metrics"
 	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/reaper"
 	"github.com/arthures11/gosynq/internal/repository"
+	"github.com/arthures11/gosynq/internal/scheduler"
 	"github.com/arthures11/gosynq/internal/worker"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Dispatcher struct {
-	repo       *repository.PostgresRepository
-	workers    []*worker.Worker
+	// id names this node in the locked_by column of the jobs it claims
+	id   string
+	repo repository.Store
+	// workerPool holds one slot per busy or reserved worker
 	workerPool chan struct{}
+	// jobs carries claimed jobs from the fetch loop to the workers
+	jobs       chan *models.Job
 	eventChan  chan models.JobEvent
 	shutdownCh chan struct{}
 	shutdownWg sync.WaitGroup
+	fetchWg    sync.WaitGroup
 	config     DispatcherConfig
 	metrics    *metrics.Metrics
+	handlers   *handlerRegistry
+	scheduler  *scheduler.Scheduler
+	reaper     *reaper.Reaper
+	wakeup     *wakeup
 }
 
+// ErrClosed is returned when enqueueing on a dispatcher that has been shut
+// down.
+var ErrClosed = errors.New("dispatcher is shut down")
+
+// ErrInvalidQueueConfig is returned by ConfigureQueue for out-of-range
+// settings.
+var ErrInvalidQueueConfig = errors.New("invalid queue configuration")
+
 type DispatcherConfig struct {
 	WorkerPoolSize    int
 	VisibilityTimeout time.Duration
 	RetryStrategy     worker.RetryStrategy
+	// Queues restricts every worker in the pool to these queues, so pools on
+	// different hosts can be dedicated to different work. Empty means all.
+	Queues []string
+	// SchedulerPollInterval is how often cron schedules are checked.
+	SchedulerPollInterval time.Duration
+	// HeartbeatInterval is how often workers extend the lease on a running
+	// job. Zero disables automatic heartbeats.
+	HeartbeatInterval time.Duration
+	// ReaperInterval is how often jobs with expired leases are reclaimed.
+	ReaperInterval time.Duration
+	// IdempotencyRetention is how long a job's idempotency key blocks
+	// duplicates. Zero means forever.
+	IdempotencyRetention time.Duration
+	// ListenDSN is a Postgres connection string to LISTEN for ready jobs
+	// on. Empty leaves the dispatcher polling for them.
+	ListenDSN string
+	// PollInterval is the longest the dispatcher goes without looking for
+	// jobs while workers are free. Defaults to one second.
+	PollInterval time.Duration
+	// Registerer receives the dispatcher's metrics. Nil registers them
+	// with a registry of the dispatcher's own.
+	Registerer prometheus.Registerer
 }
 
-func NewDispatcher(repo *repository.PostgresRepository, config DispatcherConfig) *Dispatcher {
+func NewDispatcher(repo repository.Store, config DispatcherConfig) *Dispatcher {
+	eventChan := make(chan models.JobEvent, 100) // Buffered channel
+
+	registerer := config.Registerer
+	if registerer == nil {
+		registerer = prometheus.NewRegistry()
+	}
+
 	return &Dispatcher{
+		id:         nodeID(),
 		repo:       repo,
 		workerPool: make(chan struct{}, config.WorkerPoolSize),
-		eventChan:  make(chan models.JobEvent, 100), // Buffered channel
+		jobs:       make(chan *models.Job),
+		eventChan:  eventChan,
 		shutdownCh: make(chan struct{}),
 		config:     config,
-		metrics:    metrics.NewMetrics(),
+		metrics:    metrics.NewMetrics(registerer),
+		handlers:   newHandlerRegistry(),
+		wakeup:     newWakeup(),
+		scheduler: scheduler.New(repo, eventChan, scheduler.Config{
+			PollInterval: config.SchedulerPollInterval,
+		}),
+		reaper: reaper.New(repo, eventChan, reaper.Config{
+			Interval:    config.ReaperInterval,
+			MaxAttempts: config.RetryStrategy.MaxAttempts,
+		}),
 	}
 }
 
@@ -50,38 +113,32 @@ func (d *Dispatcher) Start(ctx context.Context) {
 		d.startWorker(i)
 	}
 
-	// Start event processor (for WebSocket, etc.)
-	// The WebSocket server should already be started and listening to d.eventChan
-	// Events will flow automatically to WebSocket clients
-	go d.processEvents(ctx)
-}
-
-func (d *Dispatcher) startWorker(id int) {
-	workerID := fmt.Sprintf("worker-%d", id)
+	d.fetchWg.Add(1)
+	go d.fetch()
 
-	jobHandler := func(ctx context.Context, job *models.Job) error {
-		// This is where the actual job processing would happen
-		// For now, we'll just log it and simulate some work
-		log.Printf("Worker %s processing job %s from queue %s", workerID, job.ID, job.Queue)
+	d.scheduler.Start(ctx)
+	d.reaper.Start(ctx)
 
-		// Simulate work
-		time.Sleep(1 * time.Second)
+	if d.config.ListenDSN != "" {
+		d.fetchWg.Add(1)
+		go d.listen()
+	}
 
-		// Simulate random failures for demo purposes
-		if rand.Float32() < 0.2 { // 20% chance of failure
-			return fmt.Errorf("simulated processing error")
-		}
+	// Events are consumed by the WebSocket server, which reads d.eventChan
+	// through GetEventChannel. Nothing else may read from it.
+}
 
-		return nil
-	}
+func (d *Dispatcher) startWorker(id int) {
+	workerID := fmt.Sprintf("%s/worker-%d", d.id, id)
 
 	worker := worker.NewWorker(
 		workerID,
 		d.repo,
-		jobHandler,
+		d.handleJob,
 		worker.WorkerConfig{
 			VisibilityTimeout: d.config.VisibilityTimeout,
 			RetryStrategy:     d.config.RetryStrategy,
+			HeartbeatInterval: d.config.HeartbeatInterval,
 		},
 		d.eventChan,
 	)
@@ -89,56 +146,219 @@ func (d *Dispatcher) startWorker(id int) {
 	d.shutdownWg.Add(1)
 	go func() {
 		defer d.shutdownWg.Done()
-		worker.Start(context.Background())
+		worker.Start(context.Background(), d.jobs, func() { <-d.workerPool })
 	}()
 }
 
-func (d *Dispatcher) processEvents(ctx context.Context) {
-	for {
-		select {
-		case <-d.shutdownCh:
-			log.Println("Dispatcher event processor shutting down")
-			return
-		case event := <-d.eventChan:
-			// Broadcast event to WebSocket clients
-			// The event channel is already connected to WebSocket server
-			// Just need to ensure events flow through properly
-			log.Printf("Dispatcher received event: %s - Job %s from queue %s", event.Type, event.JobID, event.Queue)
-			// The event will be picked up by WebSocket server automatically
-			log.Printf("Dispatcher broadcasting event to WebSocket clients")
-		}
+// nodeID returns a name for this dispatcher that is unique across nodes.
+func nodeID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "gosynq"
+	}
+	return host + "-" + uuid.New().String()[:8]
+}
+
+func (d *Dispatcher) pollInterval() time.Duration {
+	if d.config.PollInterval <= 0 {
+		return time.Second
 	}
+	return d.config.PollInterval
 }
 
 func (d *Dispatcher) GetEventChannel() <-chan models.JobEvent {
 	return d.eventChan
 }
 
-func (d *Dispatcher) EnqueueJob(ctx context.Context, job *models.Job) error {
-	// Set default values
-	if job.Status == "" {
-		job.Status = models.StatusPending
-	}
-	if job.Priority == "" {
-		job.Priority = models.PriorityNormal
+// Scheduler returns the cron scheduler, which shares the dispatcher's
+// store and event stream.
+func (d *Dispatcher) Scheduler() *scheduler.Scheduler {
+	return d.scheduler
+}
+
+// EnqueueJob stores job and announces it. If job's idempotency key is
+// already held by a job in the same queue, nothing is stored and the
+// existing job is returned; otherwise the returned job is nil.
+func (d *Dispatcher) EnqueueJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	if d.isShutdown() {
+		return nil, ErrClosed
 	}
-	if job.Queue == "" {
-		job.Queue = "default"
+	if err := prepareJobs([]*models.Job{job}); err != nil {
+		return nil, err
 	}
 
 	// Create the job in database
-	err := d.repo.CreateJob(ctx, job)
+	existing, err := d.repo.CreateJob(ctx, job, d.keysReleasedBefore())
 	if err != nil {
-		return fmt.Errorf("failed to create job: %w", err)
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	// Wake a local worker even when no listener will hear about the job
+	if !job.RunAt.After(time.Now()) {
+		d.wakeup.notify()
 	}
 
 	// Send job created event
-	d.eventChan <- models.JobEvent{
+	d.emit(models.JobEvent{
 		Type:      "created",
 		JobID:     job.ID,
 		Queue:     job.Queue,
 		Timestamp: time.Now(),
 		Payload:   job.Payload,
+	})
+
+	return nil, nil
+}
+
+// EnqueueJobs stores jobs in one transaction and announces them with a
+// single batch_created event instead of one event per job. The returned
+// slice matches jobs: nil for every job stored, otherwise the job already
+// holding its idempotency key. Nothing is stored if any job is invalid.
+func (d *Dispatcher) EnqueueJobs(ctx context.Context, jobs []*models.Job) ([]*models.Job, error) {
+	if d.isShutdown() {
+		return nil, ErrClosed
+	}
+	if err := prepareJobs(jobs); err != nil {
+		return nil, err
+	}
+
+	existing, err := d.repo.CreateJobs(ctx, jobs, d.keysReleasedBefore())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jobs: %w", err)
+	}
+
+	now := time.Now()
+	created := 0
+	queues := make(map[string]int)
+	due := false
+	for i, job := range jobs {
+		if existing[i] != nil {
+			continue
+		}
+		created++
+		queues[job.Queue]++
+		if !job.RunAt.After(now) {
+			due = true
+		}
+	}
+	if created == 0 {
+		return existing, nil
+	}
+
+	if due {
+		d.wakeup.notify()
+	}
+
+	d.emit(models.JobEvent{
+		Type:      "batch_created",
+		Timestamp: now,
+		Payload: map[string]any{
+			"count":  created,
+			"queues": queues,
+		},
+	})
+
+	return existing, nil
+}
+
+// prepareJobs fills in defaults and validates retry policies.
+func prepareJobs(jobs []*models.Job) error {
+	for _, job := range jobs {
+		// Set default values
+		if job.Status == "" {
+			job.Status = models.StatusPending
+		}
+		if job.Priority == "" {
+			job.Priority = models.PriorityNormal
+		}
+		if job.Queue == "" {
+			job.Queue = "default"
+		}
+		if len(job.Payload) == 0 {
+			job.Payload = json.RawMessage(`{}`)
+		}
+		if job.RetryPolicy != nil {
+			if err := job.RetryPolicy.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// keysReleasedBefore is the creation time before which a job's idempotency
+// key stops blocking new jobs, or the zero time when keys are held for the
+// job's lifetime.
+func (d *Dispatcher) keysReleasedBefore() time.Time {
+	if d.config.IdempotencyRetention <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-d.config.IdempotencyRetention)
+}
+
+// PauseQueue stops every worker on every node from picking jobs in queue.
+// Jobs already being processed run to completion.
+func (d *Dispatcher) PauseQueue(ctx context.Context, queue string) error {
+	if err := d.repo.SetQueuePaused(ctx, queue, true); err != nil {
+		return fmt.Errorf("failed to pause queue: %w", err)
+	}
+
+	d.emit(models.JobEvent{
+		Type:      "queue_paused",
+		Queue:     queue,
+		Timestamp: time.Now(),
+	})
+
+	return nil
+}
+
+// ResumeQueue lets workers pick jobs in a paused queue again.
+func (d *Dispatcher) ResumeQueue(ctx context.Context, queue string) error {
+	if err := d.repo.SetQueuePaused(ctx, queue, false); err != nil {
+		return fmt.Errorf("failed to resume queue: %w", err)
+	}
+
+	d.emit(models.JobEvent{
+		Type:      "queue_resumed",
+		Queue:     queue,
+		Timestamp: time.Now(),
+	})
+
+	return nil
+}
+
+// ConfigureQueue sets the queue's selection weight and its cluster-wide
+// limit on jobs processing at once. A weight of 0 means the default of 1
+// and a maxInFlight of 0 means unlimited.
+func (d *Dispatcher) ConfigureQueue(ctx context.Context, queue string, weight int, maxInFlight int) error {
+	if weight < 0 || maxInFlight < 0 {
+		return fmt.Errorf("%w: weight and max_in_flight must not be negative", ErrInvalidQueueConfig)
+	}
+	if weight == 0 {
+		weight = 1
+	}
+
+	if err := d.repo.ConfigureQueue(ctx, queue, weight, maxInFlight); err != nil {
+		return fmt.Errorf("failed to configure queue: %w", err)
+	}
+
+	return nil
+}
+
+// SetQueueRetryPolicy sets how jobs in queue are retried unless they carry
+// their own policy. A nil policy falls back to the server default.
+func (d *Dispatcher) SetQueueRetryPolicy(ctx context.Context, queue string, policy *models.RetryPolicy) error {
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := d.repo.SetQueueRetryPolicy(ctx, queue, policy); err != nil {
+		return fmt.Errorf("failed to set queue retry policy: %w", err)
 	}
 
 	return nil
@@ -149,9 +369,34 @@ func (d *Dispatcher) Shutdown() {
 
 	close(d.shutdownCh)
 
+	d.scheduler.Shutdown()
+	d.reaper.Shutdown()
+
+	// Stop claiming before the workers stop, so every claimed job is
+	// handed to a worker
+	d.fetchWg.Wait()
+	close(d.jobs)
+
 	// Wait for all workers to shutdown
 	d.shutdownWg.Wait()
 
-	close(d.eventChan)
 	log.Println("Dispatcher shutdown complete")
 }
+
+func (d *Dispatcher) isShutdown() bool {
+	select {
+	case <-d.shutdownCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// emit sends an event to the WebSocket server. Events raised after
+// shutdown, when nothing may be reading any more, are dropped.
+func (d *Dispatcher) emit(event models.JobEvent) {
+	select {
+	case d.eventChan <- event:
+	case <-d.shutdownCh:
+	}
+}
@@ -0,0 +1,88 @@
+package dispatcher
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// minPollInterval is how soon the fetch loop first looks for jobs again
+// after finding none.
+const minPollInterval = 50 * time.Millisecond
+
+// fetch claims jobs for free workers and hands them over on d.jobs. It
+// reserves every free slot in workerPool, claims up to that many jobs in
+// one PickJobs call and gives back the slots it could not fill. Workers
+// give their slot back when they finish a job.
+func (d *Dispatcher) fetch() {
+	defer d.fetchWg.Done()
+
+	// Workers finish their jobs after a shutdown, so claiming does too
+	ctx := context.Background()
+
+	backoff := minPollInterval
+	for {
+		select {
+		case d.workerPool <- struct{}{}:
+		case <-d.shutdownCh:
+			return
+		}
+		free := 1 + d.reserveFreeSlots()
+
+		jobs, err := d.repo.PickJobs(ctx, d.id, d.config.Queues, free, d.config.VisibilityTimeout)
+		if err != nil {
+			log.Printf("Dispatcher: failed to pick jobs: %v", err)
+		}
+		for range free - len(jobs) {
+			<-d.workerPool
+		}
+
+		// Every job has a reserved slot, so an idle worker is waiting
+		for _, job := range jobs {
+			d.jobs <- job
+		}
+
+		if len(jobs) > 0 {
+			backoff = minPollInterval
+			continue
+		}
+		if !d.waitForJobs(backoff) {
+			return
+		}
+		backoff = min(backoff*2, d.pollInterval())
+	}
+}
+
+// reserveFreeSlots takes every free slot in workerPool without waiting and
+// returns how many it took.
+func (d *Dispatcher) reserveFreeSlots() int {
+	reserved := 0
+	for {
+		select {
+		case d.workerPool <- struct{}{}:
+			reserved++
+		default:
+			return reserved
+		}
+	}
+}
+
+// waitForJobs blocks until the fetch loop is woken or, while no listener
+// is connected, until backoff has passed. It returns false on shutdown.
+func (d *Dispatcher) waitForJobs(backoff time.Duration) bool {
+	var timeout <-chan time.Time
+	if !d.wakeup.listening.Load() {
+		timer := time.NewTimer(backoff)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-d.wakeup.ch:
+		return true
+	case <-timeout:
+		return true
+	case <-d.shutdownCh:
+		return false
+	}
+}
@@ -0,0 +1,173 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/repository"
+)
+
+// countingStore records the limit of every PickJobs call and how many jobs
+// each one claimed.
+type countingStore struct {
+	repository.Store
+
+	mu      sync.Mutex
+	limits  []int
+	claimed []int
+}
+
+func (s *countingStore) PickJobs(ctx context.Context, lockedBy string, queues []string, limit int, timeout time.Duration) ([]*models.Job, error) {
+	jobs, err := s.Store.PickJobs(ctx, lockedBy, queues, limit, timeout)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = append(s.limits, limit)
+	s.claimed = append(s.claimed, len(jobs))
+	return jobs, err
+}
+
+func (s *countingStore) picks() (limits, claimed []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.limits...), append([]int(nil), s.claimed...)
+}
+
+// startDispatcher starts a dispatcher with poolSize workers on an
+// in-memory store and shuts it down when the test ends.
+func startDispatcher(t *testing.T, poolSize int, handler func(ctx context.Context, job *models.Job) error) (*Dispatcher, *countingStore) {
+	t.Helper()
+
+	store := &countingStore{Store: repository.NewMemoryRepository()}
+	d := NewDispatcher(store, DispatcherConfig{
+		WorkerPoolSize:    poolSize,
+		VisibilityTimeout: time.Minute,
+		PollInterval:      20 * time.Millisecond,
+	})
+	d.RegisterDefaultHandler(handler)
+
+	// Nothing else reads events in these tests
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-d.GetEventChannel():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		d.Shutdown()
+		close(done)
+	})
+	return d, store
+}
+
+func enqueue(t *testing.T, d *Dispatcher, n int) {
+	t.Helper()
+
+	for range n {
+		job := &models.Job{ID: uuid.New().String(), Queue: "email", Type: "test", Payload: json.RawMessage(`{}`)}
+		if _, err := d.EnqueueJob(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func countJobs(t *testing.T, d *Dispatcher, status models.JobStatus) int {
+	t.Helper()
+
+	jobs, err := d.repo.ListJobs(context.Background(), repository.JobFilter{Status: string(status)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(jobs)
+}
+
+func TestFetchClaimsABatchForFreeWorkers(t *testing.T) {
+	d, store := startDispatcher(t, 4, func(ctx context.Context, job *models.Job) error {
+		return nil
+	})
+	enqueue(t, d, 4)
+
+	d.Start(context.Background())
+	waitFor(t, "the jobs to complete", func() bool {
+		return countJobs(t, d, models.StatusCompleted) == 4
+	})
+
+	limits, claimed := store.picks()
+	if limits[0] != 4 || claimed[0] != 4 {
+		t.Errorf("first claim asked for %d jobs and got %d, want all 4 in one batch", limits[0], claimed[0])
+	}
+}
+
+func TestFetchNeverClaimsMoreThanFreeWorkers(t *testing.T) {
+	const poolSize = 3
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	unblock := make(chan struct{})
+	d, store := startDispatcher(t, poolSize, func(ctx context.Context, job *models.Job) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		<-unblock
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	d.Start(context.Background())
+	enqueue(t, d, 10)
+
+	waitFor(t, "every worker to be busy", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return running == poolSize
+	})
+
+	// With every worker busy the fetch loop must not claim the rest
+	time.Sleep(100 * time.Millisecond)
+	if n := countJobs(t, d, models.StatusProcessing); n != poolSize {
+		t.Errorf("%d jobs are processing with %d workers", n, poolSize)
+	}
+
+	// Finished jobs give their slot back, so the rest get claimed
+	close(unblock)
+	waitFor(t, "every job to complete", func() bool {
+		return countJobs(t, d, models.StatusCompleted) == 10
+	})
+
+	limits, claimed := store.picks()
+	for i, limit := range limits {
+		if limit < 1 || limit > poolSize || claimed[i] > limit {
+			t.Errorf("claim %d asked for %d jobs and got %d with %d workers", i, limit, claimed[i], poolSize)
+		}
+	}
+	if maxRunning > poolSize {
+		t.Errorf("%d handlers ran at once with %d workers", maxRunning, poolSize)
+	}
+}
@@ -0,0 +1,83 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/worker"
+)
+
+type handlerRegistry struct {
+	mu       sync.RWMutex
+	byType   map[string]worker.JobHandler
+	byQueue  map[string]worker.JobHandler
+	fallback worker.JobHandler
+}
+
+func newHandlerRegistry() *handlerRegistry {
+	return &handlerRegistry{
+		byType:  make(map[string]worker.JobHandler),
+		byQueue: make(map[string]worker.JobHandler),
+	}
+}
+
+// lookup resolves a job's handler by type first, then by queue, then falls
+// back to the default handler.
+func (r *handlerRegistry) lookup(job *models.Job) (worker.JobHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if job.Type != "" {
+		if handler, ok := r.byType[job.Type]; ok {
+			return handler, true
+		}
+	}
+	if handler, ok := r.byQueue[job.Queue]; ok {
+		return handler, true
+	}
+	if r.fallback != nil {
+		return r.fallback, true
+	}
+	return nil, false
+}
+
+// RegisterTypeHandler routes jobs of the given type to handler, regardless
+// of the queue they were enqueued in. Type handlers take precedence over
+// queue handlers.
+func (d *Dispatcher) RegisterTypeHandler(jobType string, handler worker.JobHandler) {
+	d.handlers.mu.Lock()
+	defer d.handlers.mu.Unlock()
+	d.handlers.byType[jobType] = handler
+}
+
+// RegisterHandler routes every job in the given queue to handler.
+// Registering a handler for a queue that already has one replaces it.
+func (d *Dispatcher) RegisterHandler(queue string, handler worker.JobHandler) {
+	d.handlers.mu.Lock()
+	defer d.handlers.mu.Unlock()
+	d.handlers.byQueue[queue] = handler
+}
+
+// RegisterDefaultHandler sets the handler used for jobs that match neither
+// a type nor a queue handler.
+func (d *Dispatcher) RegisterDefaultHandler(handler worker.JobHandler) {
+	d.handlers.mu.Lock()
+	defer d.handlers.mu.Unlock()
+	d.handlers.fallback = handler
+}
+
+// handleJob is the JobHandler given to every worker. It resolves the
+// registered handler for the job and fails with worker.ErrNoHandler when
+// nothing is registered.
+func (d *Dispatcher) handleJob(ctx context.Context, job *models.Job) error {
+	handler, ok := d.handlers.lookup(job)
+	if !ok {
+		if job.Type != "" {
+			return fmt.Errorf("%w for type %q in queue %q", worker.ErrNoHandler, job.Type, job.Queue)
+		}
+		return fmt.Errorf("%w for queue %q", worker.ErrNoHandler, job.Queue)
+	}
+	return handler(ctx, job)
+}
@@ -0,0 +1,74 @@
+package dispatcher
+
+import (
+	"log"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/repository"
+	"github.com/lib/pq"
+)
+
+// listenPingInterval is how often an idle listener connection is checked.
+const listenPingInterval = 90 * time.Second
+
+// listen wakes the fetch loop whenever Postgres reports a job ready in one
+// of this node's queues. While it is connected the loop stops polling, so
+// it also wakes the loop every PollInterval to pick up jobs whose run_at
+// has passed, which nothing notifies about.
+func (d *Dispatcher) listen() {
+	defer d.fetchWg.Done()
+
+	listener := pq.NewListener(d.config.ListenDSN, 100*time.Millisecond, 10*time.Second,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+				log.Println("Job listener connected")
+				d.wakeup.setListening(true)
+			case pq.ListenerEventDisconnected:
+				log.Printf("Job listener disconnected, workers fall back to polling: %v", err)
+				d.wakeup.setListening(false)
+			case pq.ListenerEventConnectionAttemptFailed:
+				log.Printf("Job listener failed to connect: %v", err)
+			}
+		})
+	defer listener.Close()
+
+	channels := []string{repository.JobsChannel}
+	if len(d.config.Queues) > 0 {
+		channels = channels[:0]
+		for _, queue := range d.config.Queues {
+			channels = append(channels, repository.QueueChannel(queue))
+		}
+	}
+	// Listen blocks until the first connection succeeds; Close releases it
+	go func() {
+		for _, channel := range channels {
+			if err := listener.Listen(channel); err != nil {
+				log.Printf("Failed to listen on %s: %v", channel, err)
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(d.pollInterval())
+	defer ticker.Stop()
+	pingTicker := time.NewTicker(listenPingInterval)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case <-d.shutdownCh:
+			d.wakeup.setListening(false)
+			return
+		case <-listener.Notify:
+			// A nil notification after a reconnect is handled by the callback
+			d.wakeup.notify()
+		case <-ticker.C:
+			if d.wakeup.listening.Load() {
+				d.wakeup.notify()
+			}
+		case <-pingTicker.C:
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package dispatcher
+
+import "sync/atomic"
+
+// wakeup tells the fetch loop that jobs may be ready. While a listener is
+// connected and delivering notifications, the loop waits for them instead
+// of polling.
+type wakeup struct {
+	ch        chan struct{}
+	listening atomic.Bool
+}
+
+func newWakeup() *wakeup {
+	return &wakeup{ch: make(chan struct{}, 1)}
+}
+
+// notify wakes the fetch loop, or makes it look again straight away the
+// next time it runs out of jobs.
+func (w *wakeup) notify() {
+	select {
+	case w.ch <- struct{}{}:
+	default:
+	}
+}
+
+// setListening records whether a listener is connected. Either way the
+// fetch loop is woken: after connecting because notifications sent while
+// disconnected are lost, after disconnecting so it goes back to polling.
+func (w *wakeup) setListening(listening bool) {
+	w.listening.Store(listening)
+	w.notify()
+}
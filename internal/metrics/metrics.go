@@ -16,38 +16,41 @@ type Metrics struct {
 	EventChannelSize prometheus.Gauge
 }
 
-func NewMetrics() *Metrics {
+// NewMetrics creates the collectors and registers them with reg, so every
+// dispatcher in a process can have its own registry.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	factory := promauto.With(reg)
 	return &Metrics{
-		JobsProcessed: promauto.NewCounter(prometheus.CounterOpts{
+		JobsProcessed: factory.NewCounter(prometheus.CounterOpts{
 			Name: "gosynq_jobs_processed_total",
 			Help: "Total number of jobs processed",
 		}),
-		JobsFailed: promauto.NewCounter(prometheus.CounterOpts{
+		JobsFailed: factory.NewCounter(prometheus.CounterOpts{
 			Name: "gosynq_jobs_failed_total",
 			Help: "Total number of jobs that failed",
 		}),
-		JobsSucceeded: promauto.NewCounter(prometheus.CounterOpts{
+		JobsSucceeded: factory.NewCounter(prometheus.CounterOpts{
 			Name: "gosynq_jobs_succeeded_total",
 			Help: "Total number of jobs that succeeded",
 		}),
-		JobsRetried: promauto.NewCounter(prometheus.CounterOpts{
+		JobsRetried: factory.NewCounter(prometheus.CounterOpts{
 			Name: "gosynq_jobs_retried_total",
 			Help: "Total number of jobs that were retried",
 		}),
-		ActiveWorkers: promauto.NewGauge(prometheus.GaugeOpts{
+		ActiveWorkers: factory.NewGauge(prometheus.GaugeOpts{
 			Name: "gosynq_active_workers",
 			Help: "Number of active workers",
 		}),
-		QueueLength: promauto.NewGauge(prometheus.GaugeOpts{
+		QueueLength: factory.NewGauge(prometheus.GaugeOpts{
 			Name: "gosynq_queue_length",
 			Help: "Current queue length",
 		}),
-		ProcessingTime: promauto.NewHistogram(prometheus.HistogramOpts{
+		ProcessingTime: factory.NewHistogram(prometheus.HistogramOpts{
 			Name:    "gosynq_job_processing_time_seconds",
 			Help:    "Time taken to process jobs",
 			Buckets: prometheus.DefBuckets,
 		}),
-		EventChannelSize: promauto.NewGauge(prometheus.GaugeOpts{
+		EventChannelSize: factory.NewGauge(prometheus.GaugeOpts{
 			Name: "gosynq_event_channel_size",
 			Help: "Current size of the event channel",
 		}),
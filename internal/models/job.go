@@ -11,8 +11,29 @@ const (
 	StatusPending    JobStatus = "pending"
 	StatusProcessing JobStatus = "processing"
 	StatusCompleted  JobStatus = "completed"
-	StatusFailed     JobStatus = "failed"
 	StatusCancelled  JobStatus = "cancelled"
+
+	// StatusFailed marks a failed attempt. The job itself goes back to
+	// pending for a retry or becomes dead, so it never has this status.
+	StatusFailed JobStatus = "failed"
+
+	// StatusDead marks a job that failed and has no retries left. Dead jobs
+	// stay until requeued or purged.
+	StatusDead JobStatus = "dead"
+
+	// StatusAbandoned marks an attempt whose lease expired before the
+	// worker finished, presumably because it died. Jobs never have this
+	// status.
+	StatusAbandoned JobStatus = "abandoned"
+
+	// StatusSnoozed marks an attempt whose handler postponed the job. Like
+	// StatusAbandoned it is never a job status.
+	StatusSnoozed JobStatus = "snoozed"
+
+	// StatusSkipped and StatusRetryAfter mark failed attempts whose handler
+	// returned SkipRetry or RetryAfter. Neither is ever a job status.
+	StatusSkipped    JobStatus = "skipped"
+	StatusRetryAfter JobStatus = "retry_after"
 )
 
 type JobPriority string
@@ -26,6 +47,7 @@ const (
 type Job struct {
 	ID             string          `json:"id"`
 	Queue          string          `json:"queue"`
+	Type           string          `json:"type"`
 	Payload        json.RawMessage `json:"payload"`
 	MaxRetries     int             `json:"max_retries"`
 	RunAt          time.Time       `json:"run_at"`
@@ -36,34 +58,52 @@ type Job struct {
 	IdempotencyKey string          `json:"idempotency_key"`
 	LockedBy       string          `json:"locked_by"`
 	LockedAt       *time.Time      `json:"locked_at,omitempty"`
+	LeaseExpiresAt *time.Time      `json:"lease_expires_at,omitempty"`
+	LeaseToken     int64           `json:"lease_token"`
+	LastError      string          `json:"last_error,omitempty"`
+	Attempts       int             `json:"attempts"`
+	Snoozes        int             `json:"snoozes"`
+	RetryPolicy    *RetryPolicy    `json:"retry_policy,omitempty"`
 }
 
 type JobAttempt struct {
-	ID            string
-	JobID         string
-	AttemptNumber int
-	StartedAt     time.Time
-	CompletedAt   *time.Time
-	Status        JobStatus
-	ErrorMessage  string
+	ID            string     `json:"id"`
+	JobID         string     `json:"job_id"`
+	AttemptNumber int        `json:"attempt_number"`
+	WorkerID      string     `json:"worker_id"`
+	StartedAt     time.Time  `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	Status        JobStatus  `json:"status"`
+	ErrorMessage  string     `json:"error_message,omitempty"`
+	DurationMs    int64      `json:"duration_ms"`
 }
 
 type EnqueueJobRequest struct {
-	Queue          string          `json:"queue"`
-	Payload        json.RawMessage `json:"payload"`
-	MaxRetries     int             `json:"max_retries"`
-	RunAt          time.Time       `json:"run_at"`
-	Priority       JobPriority     `json:"priority"`
-	IdempotencyKey string          `json:"idempotency_key"`
+	Queue      string          `json:"queue"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	MaxRetries int             `json:"max_retries"`
+	RunAt      time.Time       `json:"run_at"`
+	// RunIn delays the job by a Go duration such as "90m", as an
+	// alternative to RunAt.
+	RunIn          string       `json:"run_in,omitempty"`
+	Priority       JobPriority  `json:"priority"`
+	IdempotencyKey string       `json:"idempotency_key"`
+	Retry          *RetryPolicy `json:"retry,omitempty"`
 }
 
 type JobEvent struct {
 	Type      string      `json:"type"`
-	JobID     string      `json:"job_id"`
+	JobID     string      `json:"job_id,omitempty"`
 	Queue     string      `json:"queue"`
 	Timestamp time.Time   `json:"timestamp"`
 	Payload   interface{} `json:"payload,omitempty"`
 	Error     string      `json:"error,omitempty"`
+	// Reason is the attempt status behind a failed or dead event when the
+	// handler chose the outcome: skipped or retry_after.
+	Reason string `json:"reason,omitempty"`
+	// RunAt is when a retried or snoozed job runs next.
+	RunAt *time.Time `json:"run_at,omitempty"`
 }
 
 func (e *JobEvent) ToJSON() string {
@@ -78,6 +118,14 @@ func (j *Job) IsRetryable() bool {
 	return j.MaxRetries > 0
 }
 
+// CountedAttempts returns how many runs count against MaxRetries: every
+// attempt except those that ended in a snooze.
+func (j *Job) CountedAttempts() int {
+	return j.Attempts - j.Snoozes
+}
+
+// ShouldRetry reports whether a job that has run attempts times, the first
+// run included, may run again.
 func (j *Job) ShouldRetry(attempts int) bool {
-	return attempts < j.MaxRetries
+	return attempts <= j.MaxRetries
 }
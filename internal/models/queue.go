@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Queue struct {
+	Name   string `json:"name"`
+	Paused bool   `json:"paused"`
+	// Weight is the relative share of pickups the queue gets when several
+	// queues have runnable jobs.
+	Weight int `json:"weight"`
+	// MaxInFlight caps how many of the queue's jobs may be processing at
+	// once across the whole cluster. Zero means unlimited.
+	MaxInFlight int `json:"max_in_flight"`
+	// RetryPolicy applies to the queue's jobs where their own policy
+	// leaves a field unset.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
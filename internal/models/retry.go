@@ -0,0 +1,138 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	RetryFixed       = "fixed"
+	RetryLinear      = "linear"
+	RetryExponential = "exponential"
+)
+
+// ErrInvalidRetryPolicy is returned for retry policies with an unknown
+// strategy or out-of-range settings.
+var ErrInvalidRetryPolicy = errors.New("invalid retry policy")
+
+// Duration is a time.Duration that is written to and read from JSON and
+// YAML as a Go duration string such as "30s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// RetryPolicy decides how long a failed job waits before it runs again.
+// Zero fields are taken from the queue's policy, then from the server's
+// defaults.
+type RetryPolicy struct {
+	// Strategy is fixed, linear or exponential.
+	Strategy string `json:"strategy,omitempty" yaml:"strategy"`
+	// Interval is the delay before the first retry. Linear backoff adds it
+	// once more for every further retry; exponential backoff multiplies the
+	// delay by Base each time.
+	Interval Duration `json:"interval,omitempty" yaml:"interval"`
+	Base     float64  `json:"base,omitempty" yaml:"base"`
+	// MaxDelay caps the delay, jitter included.
+	MaxDelay Duration `json:"max_delay,omitempty" yaml:"max_delay"`
+	// Jitter moves each delay randomly by up to this fraction of it, so
+	// jobs that failed together do not all retry at once.
+	Jitter float64 `json:"jitter,omitempty" yaml:"jitter"`
+}
+
+func (p *RetryPolicy) Validate() error {
+	switch p.Strategy {
+	case "", RetryFixed, RetryLinear, RetryExponential:
+	default:
+		return fmt.Errorf("%w: unknown strategy %q", ErrInvalidRetryPolicy, p.Strategy)
+	}
+	if p.Interval < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("%w: interval and max_delay must not be negative", ErrInvalidRetryPolicy)
+	}
+	if p.Base != 0 && p.Base < 1 {
+		return fmt.Errorf("%w: base must be at least 1", ErrInvalidRetryPolicy)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("%w: jitter must be between 0 and 1", ErrInvalidRetryPolicy)
+	}
+	return nil
+}
+
+// Or returns p with its zero fields taken from fallback.
+func (p RetryPolicy) Or(fallback RetryPolicy) RetryPolicy {
+	if p.Strategy == "" {
+		p.Strategy = fallback.Strategy
+	}
+	if p.Interval == 0 {
+		p.Interval = fallback.Interval
+	}
+	if p.Base == 0 {
+		p.Base = fallback.Base
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = fallback.MaxDelay
+	}
+	if p.Jitter == 0 {
+		p.Jitter = fallback.Jitter
+	}
+	return p
+}
+
+// Delay returns how long to wait before the given retry, counting from 1.
+// random is a number in [0, 1) used for jitter.
+func (p RetryPolicy) Delay(retry int, random float64) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	delay := float64(p.Interval)
+	switch p.Strategy {
+	case RetryLinear:
+		delay *= float64(retry)
+	case RetryExponential:
+		base := p.Base
+		if base == 0 {
+			base = 2
+		}
+		delay *= math.Pow(base, float64(retry-1))
+	}
+
+	delay *= 1 + p.Jitter*(2*random-1)
+
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	second := Duration(time.Second)
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		retry  int
+		random float64
+		want   time.Duration
+	}{
+		{"fixed", RetryPolicy{Strategy: RetryFixed, Interval: second}, 5, 0.5, time.Second},
+		{"unset strategy is fixed", RetryPolicy{Interval: second}, 3, 0.5, time.Second},
+		{"linear", RetryPolicy{Strategy: RetryLinear, Interval: second}, 3, 0.5, 3 * time.Second},
+		{"exponential default base", RetryPolicy{Strategy: RetryExponential, Interval: second}, 4, 0.5, 8 * time.Second},
+		{"exponential base 3", RetryPolicy{Strategy: RetryExponential, Interval: second, Base: 3}, 3, 0.5, 9 * time.Second},
+		{"retry below 1 counts as the first", RetryPolicy{Strategy: RetryLinear, Interval: second}, 0, 0.5, time.Second},
+		{"capped", RetryPolicy{Strategy: RetryExponential, Interval: second, MaxDelay: Duration(5 * time.Second)}, 10, 0.5, 5 * time.Second},
+		{"jitter low", RetryPolicy{Interval: Duration(10 * time.Second), Jitter: 0.5}, 1, 0, 5 * time.Second},
+		{"jitter high", RetryPolicy{Interval: Duration(10 * time.Second), Jitter: 0.5}, 1, 1, 15 * time.Second},
+		{"cap applies after jitter", RetryPolicy{Interval: Duration(10 * time.Second), Jitter: 0.5, MaxDelay: Duration(12 * time.Second)}, 1, 1, 12 * time.Second},
+		{"overflow saturates", RetryPolicy{Strategy: RetryExponential, Interval: Duration(time.Hour)}, 200, 0.5, time.Duration(math.MaxInt64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Delay(tt.retry, tt.random); got != tt.want {
+				t.Errorf("Delay(%d, %v) = %v, want %v", tt.retry, tt.random, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyOr(t *testing.T) {
+	fallback := RetryPolicy{Strategy: RetryExponential, Interval: Duration(time.Second), Base: 2, MaxDelay: Duration(time.Minute), Jitter: 0.1}
+
+	got := RetryPolicy{Strategy: RetryLinear, Jitter: 0.3}.Or(fallback)
+	want := RetryPolicy{Strategy: RetryLinear, Interval: Duration(time.Second), Base: 2, MaxDelay: Duration(time.Minute), Jitter: 0.3}
+	if got != want {
+		t.Errorf("Or = %+v, want %+v", got, want)
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		valid  bool
+	}{
+		{"empty", RetryPolicy{}, true},
+		{"complete", RetryPolicy{Strategy: RetryExponential, Interval: Duration(time.Second), Base: 1.5, Jitter: 1}, true},
+		{"unknown strategy", RetryPolicy{Strategy: "random"}, false},
+		{"negative interval", RetryPolicy{Interval: Duration(-time.Second)}, false},
+		{"base below 1", RetryPolicy{Base: 0.5}, false},
+		{"jitter above 1", RetryPolicy{Jitter: 1.5}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidRetryPolicy) {
+				t.Errorf("Validate() = %v, want ErrInvalidRetryPolicy", err)
+			}
+		})
+	}
+}
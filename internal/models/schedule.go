@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Schedule enqueues a job every time its cron expression fires.
+type Schedule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Cron is a standard five-field cron expression or a descriptor such as
+	// "@hourly", evaluated in Timezone.
+	Cron       string          `json:"cron"`
+	Timezone   string          `json:"timezone"`
+	Queue      string          `json:"queue"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Priority   JobPriority     `json:"priority"`
+	MaxRetries int             `json:"max_retries"`
+	Enabled    bool            `json:"enabled"`
+	NextRunAt  time.Time       `json:"next_run_at"`
+	LastRunAt  *time.Time      `json:"last_run_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,150 @@
+// Package reaper reclaims jobs whose worker died mid-processing.
+//
+// A worker's claim on a job is a lease that expires after the visibility
+// timeout. Once it has expired the reaper records an abandoned attempt and
+// either returns the job to pending or, when its retries are used up,
+// dead-letters it. Every node runs a reaper; the store only lets one of
+// them reclaim a given lease.
+package reaper
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/repository"
+	"github.com/google/uuid"
+)
+
+// batchSize caps how many expired leases are handled per sweep.
+const batchSize = 100
+
+type Reaper struct {
+	repo       repository.Store
+	eventChan  chan<- models.JobEvent
+	config     Config
+	shutdownCh chan struct{}
+	shutdownWg sync.WaitGroup
+}
+
+type Config struct {
+	// Interval is how often expired leases are looked for.
+	Interval time.Duration
+	// MaxAttempts caps how many times any job runs. Zero means no cap.
+	MaxAttempts int
+}
+
+func New(repo repository.Store, eventChan chan<- models.JobEvent, config Config) *Reaper {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+
+	return &Reaper{
+		repo:       repo,
+		eventChan:  eventChan,
+		config:     config,
+		shutdownCh: make(chan struct{}),
+	}
+}
+
+func (r *Reaper) Start(ctx context.Context) {
+	log.Println("Starting reaper")
+
+	r.shutdownWg.Add(1)
+	go func() {
+		defer r.shutdownWg.Done()
+
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.shutdownCh:
+				return
+			case <-ticker.C:
+				r.sweep(ctx)
+			}
+		}
+	}()
+}
+
+// sweep reclaims every job whose lease has expired.
+func (r *Reaper) sweep(ctx context.Context) {
+	jobs, err := r.repo.ExpiredLeases(ctx, batchSize)
+	if err != nil {
+		log.Printf("Reaper: failed to list expired leases: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		if err := r.reclaim(ctx, job); err != nil {
+			log.Printf("Reaper: failed to reclaim job %s: %v", job.ID, err)
+		}
+	}
+}
+
+func (r *Reaper) reclaim(ctx context.Context, job *models.Job) error {
+	now := time.Now()
+	startedAt := now
+	if job.LockedAt != nil {
+		startedAt = *job.LockedAt
+	}
+
+	// AbandonJob numbers the attempt, reusing the worker's row if it has one
+	attempt := &models.JobAttempt{
+		ID:           uuid.New().String(),
+		JobID:        job.ID,
+		WorkerID:     job.LockedBy,
+		StartedAt:    startedAt,
+		CompletedAt:  &now,
+		Status:       models.StatusAbandoned,
+		ErrorMessage: "lease expired while held by " + job.LockedBy,
+		DurationMs:   now.Sub(startedAt).Milliseconds(),
+	}
+
+	// Same rule as a failed attempt: retry while attempts remain
+	status := models.StatusDead
+	attempts := job.CountedAttempts()
+	capped := r.config.MaxAttempts > 0 && attempts >= r.config.MaxAttempts
+	if job.IsRetryable() && job.ShouldRetry(attempts) && !capped {
+		status = models.StatusPending
+	}
+
+	reclaimed, err := r.repo.AbandonJob(ctx, job, status, attempt)
+	if err != nil || !reclaimed {
+		return err
+	}
+
+	log.Printf("Reaper: job %s abandoned by %s, now %s", job.ID, job.LockedBy, status)
+
+	r.eventChan <- models.JobEvent{
+		Type:      "abandoned",
+		JobID:     job.ID,
+		Queue:     job.Queue,
+		Timestamp: now,
+		Payload:   job.Payload,
+		Error:     attempt.ErrorMessage,
+	}
+
+	if status == models.StatusDead {
+		r.eventChan <- models.JobEvent{
+			Type:      "dead",
+			JobID:     job.ID,
+			Queue:     job.Queue,
+			Timestamp: now,
+			Payload:   job.Payload,
+			Error:     attempt.ErrorMessage,
+		}
+	}
+
+	return nil
+}
+
+func (r *Reaper) Shutdown() {
+	log.Println("Shutting down reaper")
+
+	close(r.shutdownCh)
+	r.shutdownWg.Wait()
+}
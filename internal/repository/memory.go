@@ -0,0 +1,736 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+// MemoryRepository is a Store kept entirely in process memory. It mirrors
+// the PostgreSQL behaviour closely enough for unit tests and local demos,
+// but nothing survives a restart and it cannot be shared between nodes.
+type MemoryRepository struct {
+	mu        sync.Mutex
+	jobs      map[string]*models.Job
+	attempts  map[string][]*models.JobAttempt
+	queues    map[string]*models.Queue
+	schedules map[string]*models.Schedule
+	// keys maps a queue and idempotency key to the job holding it.
+	keys map[idempotencyKey]*models.Job
+}
+
+type idempotencyKey struct {
+	queue string
+	key   string
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		jobs:      make(map[string]*models.Job),
+		attempts:  make(map[string][]*models.JobAttempt),
+		queues:    make(map[string]*models.Queue),
+		schedules: make(map[string]*models.Schedule),
+		keys:      make(map[idempotencyKey]*models.Job),
+	}
+}
+
+func (r *MemoryRepository) CreateJob(ctx context.Context, job *models.Job, releaseBefore time.Time) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insertJob(job, releaseBefore)
+}
+
+func (r *MemoryRepository) CreateJobs(ctx context.Context, jobs []*models.Job, releaseBefore time.Time) ([]*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check every ID up front so a failure leaves nothing half inserted
+	ids := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		if _, exists := r.jobs[job.ID]; exists || ids[job.ID] {
+			return nil, fmt.Errorf("job %s already exists", job.ID)
+		}
+		ids[job.ID] = true
+	}
+
+	existing := make([]*models.Job, len(jobs))
+	for i, job := range jobs {
+		holder, err := r.insertJob(job, releaseBefore)
+		if err != nil {
+			return nil, err
+		}
+		existing[i] = holder
+	}
+	return existing, nil
+}
+
+// insertJob stores job unless its idempotency key is held, in which case it
+// returns the holder. A holder created before releaseBefore gives the key
+// up instead.
+func (r *MemoryRepository) insertJob(job *models.Job, releaseBefore time.Time) (*models.Job, error) {
+	if _, exists := r.jobs[job.ID]; exists {
+		return nil, fmt.Errorf("job %s already exists", job.ID)
+	}
+
+	key := idempotencyKey{queue: job.Queue, key: job.IdempotencyKey}
+	if holder, ok := r.keys[key]; ok && job.IdempotencyKey != "" {
+		if !holder.CreatedAt.Before(releaseBefore) {
+			return cloneJob(holder), nil
+		}
+		holder.IdempotencyKey = ""
+	}
+
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	job.Status = models.StatusPending
+	if job.Priority == "" {
+		job.Priority = models.PriorityNormal
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+
+	stored := cloneJob(job)
+	r.jobs[job.ID] = stored
+	if job.IdempotencyKey != "" {
+		r.keys[key] = stored
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) GetJobByID(ctx context.Context, id string) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneJob(job), nil
+}
+
+// PickJobs claims up to limit runnable jobs from the given queues, or from
+// any queue when queues is empty, sharing the batch between queues by
+// weight and skipping paused queues and queues at their max_in_flight
+// limit.
+func (r *MemoryRepository) PickJobs(ctx context.Context, lockedBy string, queues []string, limit int, timeout time.Duration) ([]*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	subscribed := make(map[string]bool, len(queues))
+	for _, queue := range queues {
+		subscribed[queue] = true
+	}
+
+	// Runnable jobs and in-flight count per queue
+	runnableJobs := make(map[string][]*models.Job)
+	inFlight := make(map[string]int)
+	for _, job := range r.jobs {
+		if job.Status == models.StatusProcessing {
+			inFlight[job.Queue]++
+			continue
+		}
+		if job.Status != models.StatusPending || job.RunAt.After(now) {
+			continue
+		}
+		if len(subscribed) > 0 && !subscribed[job.Queue] {
+			continue
+		}
+		runnableJobs[job.Queue] = append(runnableJobs[job.Queue], job)
+	}
+
+	var runnable []*models.Queue
+	for name, jobs := range runnableJobs {
+		queue := r.queueState(name)
+		if queue.Paused {
+			continue
+		}
+		sortForPickup(jobs)
+		runnable = append(runnable, queue)
+	}
+
+	return pickBatch(runnable, limit, func(queue *models.Queue, limit int) ([]*models.Job, error) {
+		if queue.MaxInFlight > 0 {
+			limit = min(limit, queue.MaxInFlight-inFlight[queue.Name])
+		}
+
+		var picked []*models.Job
+		for len(picked) < limit && len(runnableJobs[queue.Name]) > 0 {
+			job := runnableJobs[queue.Name][0]
+			runnableJobs[queue.Name] = runnableJobs[queue.Name][1:]
+
+			job.Status = models.StatusProcessing
+			job.LockedBy = lockedBy
+			job.LockedAt = &now
+			leaseExpiresAt := now.Add(timeout)
+			job.LeaseExpiresAt = &leaseExpiresAt
+			job.LeaseToken++
+			job.Attempts++
+			job.UpdatedAt = now
+			inFlight[queue.Name]++
+
+			picked = append(picked, cloneJob(job))
+		}
+		return picked, nil
+	})
+}
+
+// queueState returns a copy of the queue's stored state, or the defaults
+// for a queue that was never configured.
+func (r *MemoryRepository) queueState(name string) *models.Queue {
+	if queue, ok := r.queues[name]; ok {
+		copied := *queue
+		copied.RetryPolicy = cloneRetryPolicy(queue.RetryPolicy)
+		return &copied
+	}
+	return &models.Queue{Name: name, Weight: 1}
+}
+
+func (r *MemoryRepository) ExtendLease(ctx context.Context, jobID string, leaseToken int64, timeout time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.leasedJob(jobID, leaseToken)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	leaseExpiresAt := now.Add(timeout)
+	job.LockedAt = &now
+	job.LeaseExpiresAt = &leaseExpiresAt
+	job.UpdatedAt = now
+	return nil
+}
+
+func (r *MemoryRepository) FinishJob(ctx context.Context, jobID string, leaseToken int64, status models.JobStatus, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.leasedJob(jobID, leaseToken)
+	if err != nil {
+		return err
+	}
+	job.Status = status
+	job.LastError = lastError
+	job.LockedBy = ""
+	job.LockedAt = nil
+	job.LeaseExpiresAt = nil
+	job.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryRepository) UpdateJobStatus(ctx context.Context, jobID string, status models.JobStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return nil
+	}
+	job.Status = status
+	job.LockedBy = ""
+	job.LockedAt = nil
+	job.LeaseExpiresAt = nil
+	job.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryRepository) UpdateJobForRetry(ctx context.Context, jobID string, leaseToken int64, runAt time.Time, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.leasedJob(jobID, leaseToken)
+	if err != nil {
+		return err
+	}
+	job.Status = models.StatusPending
+	job.RunAt = runAt
+	job.LastError = lastError
+	job.LockedBy = ""
+	job.LockedAt = nil
+	job.LeaseExpiresAt = nil
+	job.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryRepository) SnoozeJob(ctx context.Context, jobID string, leaseToken int64, runAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, err := r.leasedJob(jobID, leaseToken)
+	if err != nil {
+		return err
+	}
+	job.Status = models.StatusPending
+	job.RunAt = runAt
+	job.Snoozes++
+	job.LockedBy = ""
+	job.LockedAt = nil
+	job.LeaseExpiresAt = nil
+	job.UpdatedAt = time.Now()
+	return nil
+}
+
+// leasedJob returns the stored job if it is still processing under
+// leaseToken.
+func (r *MemoryRepository) leasedJob(jobID string, leaseToken int64) (*models.Job, error) {
+	job, ok := r.jobs[jobID]
+	if !ok || job.LeaseToken != leaseToken || job.Status != models.StatusProcessing {
+		return nil, ErrLeaseLost
+	}
+	return job, nil
+}
+
+func (r *MemoryRepository) CreateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insertJobAttempt(attempt)
+}
+
+func (r *MemoryRepository) insertJobAttempt(attempt *models.JobAttempt) error {
+	if _, ok := r.jobs[attempt.JobID]; !ok {
+		return fmt.Errorf("job %s does not exist", attempt.JobID)
+	}
+
+	// Enforce the same uniqueness as the job_attempts table
+	for _, attempts := range r.attempts {
+		for _, existing := range attempts {
+			if existing.ID == attempt.ID {
+				return fmt.Errorf("job attempt %s already exists", attempt.ID)
+			}
+		}
+	}
+
+	// Number it after the job's existing attempts, like the SQL stores
+	attempt.AttemptNumber = 1
+	for _, existing := range r.attempts[attempt.JobID] {
+		attempt.AttemptNumber = max(attempt.AttemptNumber, existing.AttemptNumber+1)
+	}
+
+	stored := *attempt
+	if stored.StartedAt.IsZero() {
+		stored.StartedAt = time.Now()
+	}
+	r.attempts[attempt.JobID] = append(r.attempts[attempt.JobID], &stored)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.attempts[attempt.JobID] {
+		if existing.ID == attempt.ID && existing.Status == models.StatusProcessing {
+			existing.CompletedAt = attempt.CompletedAt
+			existing.Status = attempt.Status
+			existing.ErrorMessage = attempt.ErrorMessage
+			existing.DurationMs = attempt.DurationMs
+		}
+	}
+	return nil
+}
+
+// runningJobAttempt returns the job's attempt that is still processing, if
+// any. Earlier attempts are all finished, so there is at most one.
+func (r *MemoryRepository) runningJobAttempt(jobID string) *models.JobAttempt {
+	for _, existing := range r.attempts[jobID] {
+		if existing.Status == models.StatusProcessing {
+			return existing
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ExpiredLeases(ctx context.Context, limit int) ([]*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var jobs []*models.Job
+	for _, job := range r.jobs {
+		if job.Status == models.StatusProcessing && job.LeaseExpiresAt != nil && !job.LeaseExpiresAt.After(now) {
+			jobs = append(jobs, cloneJob(job))
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].LeaseExpiresAt.Before(*jobs[j].LeaseExpiresAt)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	return jobs, nil
+}
+
+func (r *MemoryRepository) AbandonJob(ctx context.Context, job *models.Job, status models.JobStatus, attempt *models.JobAttempt) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	stored, err := r.leasedJob(job.ID, job.LeaseToken)
+	if err != nil || stored.LeaseExpiresAt == nil || stored.LeaseExpiresAt.After(now) {
+		return false, nil
+	}
+
+	// The worker may have recorded the start of this attempt before dying
+	if existing := r.runningJobAttempt(attempt.JobID); existing != nil {
+		attempt.AttemptNumber = existing.AttemptNumber
+		existing.CompletedAt = attempt.CompletedAt
+		existing.Status = attempt.Status
+		existing.ErrorMessage = attempt.ErrorMessage
+		existing.DurationMs = attempt.DurationMs
+	} else if err := r.insertJobAttempt(attempt); err != nil {
+		return false, err
+	}
+
+	stored.Status = status
+	if status == models.StatusPending {
+		stored.RunAt = now
+	}
+	stored.LastError = attempt.ErrorMessage
+	stored.LockedBy = ""
+	stored.LockedAt = nil
+	stored.LeaseExpiresAt = nil
+	stored.UpdatedAt = now
+
+	return true, nil
+}
+
+func (r *MemoryRepository) ListJobs(ctx context.Context, filter JobFilter) ([]*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var jobs []*models.Job
+	for _, job := range r.jobs {
+		if filter.Scheduled && !job.RunAt.After(now) {
+			continue
+		}
+		if filter.Status != "" && string(job.Status) != filter.Status {
+			continue
+		}
+		if filter.Queue != "" && job.Queue != filter.Queue {
+			continue
+		}
+		if filter.Type != "" && job.Type != filter.Type {
+			continue
+		}
+		jobs = append(jobs, cloneJob(job))
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if filter.Scheduled && !jobs[i].RunAt.Equal(jobs[j].RunAt) {
+			return jobs[i].RunAt.Before(jobs[j].RunAt)
+		}
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(jobs) > filter.Limit {
+		jobs = jobs[:filter.Limit]
+	}
+
+	return jobs, nil
+}
+
+func (r *MemoryRepository) GetJobAttempts(ctx context.Context, jobID string) ([]*models.JobAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var attempts []*models.JobAttempt
+	for _, attempt := range r.attempts[jobID] {
+		copied := *attempt
+		attempts = append(attempts, &copied)
+	}
+
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].AttemptNumber < attempts[j].AttemptNumber
+	})
+
+	return attempts, nil
+}
+
+func (r *MemoryRepository) GetJobStats(ctx context.Context) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make(map[string]int)
+	for _, job := range r.jobs {
+		stats[string(job.Status)]++
+	}
+	return stats, nil
+}
+
+func (r *MemoryRepository) SetQueuePaused(ctx context.Context, queue string, paused bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q := r.queueState(queue)
+	q.Paused = paused
+	q.UpdatedAt = time.Now()
+	r.queues[queue] = q
+	return nil
+}
+
+func (r *MemoryRepository) ConfigureQueue(ctx context.Context, queue string, weight int, maxInFlight int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q := r.queueState(queue)
+	q.Weight = weight
+	q.MaxInFlight = maxInFlight
+	q.UpdatedAt = time.Now()
+	r.queues[queue] = q
+	return nil
+}
+
+func (r *MemoryRepository) SetQueueRetryPolicy(ctx context.Context, queue string, policy *models.RetryPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	q := r.queueState(queue)
+	q.RetryPolicy = cloneRetryPolicy(policy)
+	q.UpdatedAt = time.Now()
+	r.queues[queue] = q
+	return nil
+}
+
+func (r *MemoryRepository) GetQueue(ctx context.Context, name string) (*models.Queue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.queues[name]; !ok {
+		return nil, nil
+	}
+	return r.queueState(name), nil
+}
+
+func (r *MemoryRepository) ListQueues(ctx context.Context) ([]*models.Queue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var queues []*models.Queue
+	for name := range r.queues {
+		queues = append(queues, r.queueState(name))
+	}
+
+	sort.Slice(queues, func(i, j int) bool {
+		return queues[i].Name < queues[j].Name
+	})
+
+	return queues, nil
+}
+
+func (r *MemoryRepository) RequeueDeadJobs(ctx context.Context, filter DeadJobFilter) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	requeued := 0
+	for _, job := range r.deadJobs(filter) {
+		job.Status = models.StatusPending
+		job.RunAt = now
+		job.Attempts = 0
+		job.Snoozes = 0
+		job.LastError = ""
+		job.UpdatedAt = now
+		requeued++
+	}
+
+	return requeued, nil
+}
+
+func (r *MemoryRepository) PurgeDeadJobs(ctx context.Context, filter DeadJobFilter) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for _, job := range r.deadJobs(filter) {
+		delete(r.jobs, job.ID)
+		delete(r.attempts, job.ID)
+		if job.IdempotencyKey != "" {
+			delete(r.keys, idempotencyKey{queue: job.Queue, key: job.IdempotencyKey})
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (r *MemoryRepository) deadJobs(filter DeadJobFilter) []*models.Job {
+	ids := make(map[string]bool, len(filter.IDs))
+	for _, id := range filter.IDs {
+		ids[id] = true
+	}
+
+	var jobs []*models.Job
+	for _, job := range r.jobs {
+		if job.Status != models.StatusDead {
+			continue
+		}
+		if filter.Queue != "" && job.Queue != filter.Queue {
+			continue
+		}
+		if len(ids) > 0 && !ids[job.ID] {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs
+}
+
+func (r *MemoryRepository) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.schedules[schedule.ID]; exists {
+		return fmt.Errorf("schedule %s already exists", schedule.ID)
+	}
+
+	now := time.Now()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	r.schedules[schedule.ID] = cloneSchedule(schedule)
+	return nil
+}
+
+func (r *MemoryRepository) GetSchedule(ctx context.Context, id string) (*models.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedule, ok := r.schedules[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneSchedule(schedule), nil
+}
+
+func (r *MemoryRepository) ListSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var schedules []*models.Schedule
+	for _, schedule := range r.schedules {
+		schedules = append(schedules, cloneSchedule(schedule))
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+
+	return schedules, nil
+}
+
+func (r *MemoryRepository) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.schedules[schedule.ID]
+	if !ok {
+		return nil
+	}
+
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.LastRunAt = existing.LastRunAt
+	schedule.UpdatedAt = time.Now()
+
+	r.schedules[schedule.ID] = cloneSchedule(schedule)
+	return nil
+}
+
+func (r *MemoryRepository) DeleteSchedule(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.schedules, id)
+	return nil
+}
+
+func (r *MemoryRepository) DueSchedules(ctx context.Context, now time.Time) ([]*models.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var schedules []*models.Schedule
+	for _, schedule := range r.schedules {
+		if schedule.Enabled && !schedule.NextRunAt.After(now) {
+			schedules = append(schedules, cloneSchedule(schedule))
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].NextRunAt.Before(schedules[j].NextRunAt)
+	})
+
+	return schedules, nil
+}
+
+func (r *MemoryRepository) FireSchedule(ctx context.Context, scheduleID string, runAt, nextRunAt time.Time, job *models.Job) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedule, ok := r.schedules[scheduleID]
+	if !ok || !schedule.Enabled || !schedule.NextRunAt.Equal(runAt) {
+		return false, nil
+	}
+	if _, err := r.insertJob(job, time.Time{}); err != nil {
+		return false, err
+	}
+
+	lastRunAt := runAt
+	schedule.NextRunAt = nextRunAt
+	schedule.LastRunAt = &lastRunAt
+	schedule.UpdatedAt = time.Now()
+
+	return true, nil
+}
+
+func cloneJob(job *models.Job) *models.Job {
+	copied := *job
+	if job.Payload != nil {
+		copied.Payload = append([]byte(nil), job.Payload...)
+	}
+	if job.LockedAt != nil {
+		lockedAt := *job.LockedAt
+		copied.LockedAt = &lockedAt
+	}
+	if job.LeaseExpiresAt != nil {
+		leaseExpiresAt := *job.LeaseExpiresAt
+		copied.LeaseExpiresAt = &leaseExpiresAt
+	}
+	copied.RetryPolicy = cloneRetryPolicy(job.RetryPolicy)
+	return &copied
+}
+
+func cloneRetryPolicy(policy *models.RetryPolicy) *models.RetryPolicy {
+	if policy == nil {
+		return nil
+	}
+	copied := *policy
+	return &copied
+}
+
+func cloneSchedule(schedule *models.Schedule) *models.Schedule {
+	copied := *schedule
+	if schedule.Payload != nil {
+		copied.Payload = append([]byte(nil), schedule.Payload...)
+	}
+	if schedule.LastRunAt != nil {
+		lastRunAt := *schedule.LastRunAt
+		copied.LastRunAt = &lastRunAt
+	}
+	return &copied
+}
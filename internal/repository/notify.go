@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+)
+
+// JobsChannel is the Postgres notification channel told about every job
+// that becomes ready to run. The notifications are sent by triggers
+// installed with the migrations.
+const JobsChannel = "gosynq_jobs"
+
+// QueueChannel returns the notification channel for jobs in queue only.
+func QueueChannel(queue string) string {
+	sum := md5.Sum([]byte(queue))
+	return JobsChannel + "_" + hex.EncodeToString(sum[:])
+}
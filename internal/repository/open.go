@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/config"
+)
+
+// Open connects to the backend selected by cfg.Driver and returns a Store
+// for it together with the database handle, which the caller must close.
+// SQLite databases are migrated on open; PostgreSQL migrations are applied
+// separately from migrations/sql.
+func Open(ctx context.Context, cfg config.DatabaseConfig) (Store, *sql.DB, error) {
+	switch cfg.Driver {
+	case "", "postgres":
+		db, err := OpenPostgres(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewPostgresRepository(db), db, nil
+
+	case "sqlite":
+		db, err := OpenSQLite(cfg.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		repo := NewSQLiteRepository(db)
+		if err := repo.Migrate(ctx); err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return repo, db, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// PostgresDSN returns the connection string for the PostgreSQL server
+// described by cfg.
+func PostgresDSN(cfg config.DatabaseConfig) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+}
+
+// OpenPostgres connects to the PostgreSQL server described by cfg.
+func OpenPostgres(cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", PostgresDSN(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// Set connection pool settings
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	return db, nil
+}
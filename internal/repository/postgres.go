@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/arthures11/gosynq/internal/models"
@@ -17,41 +20,181 @@ func NewPostgresRepository(db *sql.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
-func (r *PostgresRepository) CreateJob(ctx context.Context, job *models.Job) error {
+func (r *PostgresRepository) CreateJob(ctx context.Context, job *models.Job, releaseBefore time.Time) (*models.Job, error) {
+	if releaseBefore.IsZero() || job.IdempotencyKey == "" {
+		return r.insertJob(ctx, r.db, job)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := r.releaseIdempotencyKeys(ctx, tx, job.Queue, []string{job.IdempotencyKey}, releaseBefore); err != nil {
+		return nil, err
+	}
+	existing, err := r.insertJob(ctx, tx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, tx.Commit()
+}
+
+func (r *PostgresRepository) insertJob(ctx context.Context, q queryer, job *models.Job) (*models.Job, error) {
 	query := `
 		INSERT INTO jobs (
-			id, queue, payload, max_retries, run_at, priority, idempotency_key
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			id, queue, type, payload, max_retries, run_at, priority, idempotency_key, retry_policy
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)
+		ON CONFLICT (queue, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
 		RETURNING created_at, updated_at
 	`
 
-	err := r.db.QueryRowContext(ctx,
+	retryPolicy, err := encodeRetryPolicy(job.RetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.QueryRowContext(ctx,
 		query,
-		job.ID, job.Queue, job.Payload, job.MaxRetries, job.RunAt,
-		job.Priority, job.IdempotencyKey,
+		job.ID, job.Queue, job.Type, job.Payload, job.MaxRetries, job.RunAt,
+		job.Priority, job.IdempotencyKey, retryPolicy,
 	).Scan(&job.CreatedAt, &job.UpdatedAt)
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
 
-	return err
+	// The idempotency key is taken
+	existing, err := r.getJob(ctx, q, `queue = $1 AND idempotency_key = $2`, job.Queue, job.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("idempotency key %q is held by a job that no longer exists", job.IdempotencyKey)
+	}
+
+	return existing, nil
 }
 
-func (r *PostgresRepository) GetJobByID(ctx context.Context, id string) (*models.Job, error) {
+// CreateJobs inserts jobs with multi-row INSERTs of up to insertBatchSize
+// rows each, all in one transaction.
+func (r *PostgresRepository) CreateJobs(ctx context.Context, jobs []*models.Job, releaseBefore time.Time) ([]*models.Job, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if !releaseBefore.IsZero() {
+		for queue, keys := range idempotencyKeysByQueue(jobs) {
+			if err := r.releaseIdempotencyKeys(ctx, tx, queue, keys, releaseBefore); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	existing := make([]*models.Job, len(jobs))
+	for start := 0; start < len(jobs); start += insertBatchSize {
+		end := min(start+insertBatchSize, len(jobs))
+		if err := r.insertJobBatch(ctx, tx, jobs[start:end], existing[start:end]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// insertJobBatch inserts jobs in a single statement and fills existing
+// with the key holder of every job that was skipped.
+func (r *PostgresRepository) insertJobBatch(ctx context.Context, tx *sql.Tx, jobs []*models.Job, existing []*models.Job) error {
+	const columns = 9
+
+	var values strings.Builder
+	args := make([]any, 0, len(jobs)*columns)
+	for i, job := range jobs {
+		retryPolicy, err := encodeRetryPolicy(job.RetryPolicy)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		n := i * columns
+		fmt.Fprintf(&values, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, NULLIF($%d, ''), $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9)
+
+		args = append(args,
+			job.ID, job.Queue, job.Type, []byte(job.Payload), job.MaxRetries, job.RunAt,
+			job.Priority, job.IdempotencyKey, retryPolicy,
+		)
+	}
+
 	query := `
-		SELECT id, queue, payload, max_retries, run_at, created_at, updated_at,
-		       status, priority, idempotency_key, locked_by, locked_at
-		FROM jobs
-		WHERE id = $1
+		INSERT INTO jobs (
+			id, queue, type, payload, max_retries, run_at, priority, idempotency_key, retry_policy
+		) VALUES ` + values.String() + `
+		ON CONFLICT (queue, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+		RETURNING id, created_at, updated_at
 	`
 
-	var job models.Job
-	var lockedBy sql.NullString
-	var lockedAt pq.NullTime
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&job.ID, &job.Queue, &job.Payload, &job.MaxRetries, &job.RunAt,
-		&job.CreatedAt, &job.UpdatedAt, &job.Status, &job.Priority,
-		&job.IdempotencyKey, &lockedBy, &lockedAt,
-	)
+	byID := make(map[string]*models.Job, len(jobs))
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
 
+	inserted := make(map[string]bool, len(jobs))
+	for rows.Next() {
+		var id string
+		var createdAt, updatedAt time.Time
+		if err := rows.Scan(&id, &createdAt, &updatedAt); err != nil {
+			return err
+		}
+		byID[id].CreatedAt = createdAt
+		byID[id].UpdatedAt = updatedAt
+		inserted[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	// The rest lost their idempotency key to an existing job
+	for i, job := range jobs {
+		if inserted[job.ID] {
+			continue
+		}
+		holder, err := r.getJob(ctx, tx, `queue = $1 AND idempotency_key = $2`, job.Queue, job.IdempotencyKey)
+		if err != nil {
+			return err
+		}
+		if holder == nil {
+			return fmt.Errorf("idempotency key %q is held by a job that no longer exists", job.IdempotencyKey)
+		}
+		existing[i] = holder
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) GetJobByID(ctx context.Context, id string) (*models.Job, error) {
+	return r.getJob(ctx, r.db, `id = $1`, id)
+}
+
+func (r *PostgresRepository) getJob(ctx context.Context, q queryer, where string, args ...any) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE ` + where
+
+	job, err := scanJob(q.QueryRowContext(ctx, query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -59,70 +202,183 @@ func (r *PostgresRepository) GetJobByID(ctx context.Context, id string) (*models
 		return nil, err
 	}
 
-	if lockedBy.Valid {
-		job.LockedBy = lockedBy.String
-	}
+	return job, nil
+}
 
-	if lockedAt.Valid {
-		job.LockedAt = &lockedAt.Time
-	}
+// releaseIdempotencyKeys frees keys in queue that are held by jobs
+// created before createdBefore, so new jobs may claim them.
+func (r *PostgresRepository) releaseIdempotencyKeys(ctx context.Context, q queryer, queue string, keys []string, createdBefore time.Time) error {
+	query := `
+		UPDATE jobs
+		SET idempotency_key = NULL
+		WHERE queue = $1 AND idempotency_key = ANY($2) AND created_at < $3
+	`
 
-	return &job, nil
+	_, err := q.ExecContext(ctx, query, queue, pq.Array(keys), createdBefore)
+	return err
 }
 
-func (r *PostgresRepository) PickJob(ctx context.Context, workerID string, timeout time.Duration) (*models.Job, error) {
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelSerializable,
-	})
+// PickJobs claims up to limit runnable jobs from the given queues, or from
+// any queue when queues is empty, in one transaction. The batch is shared
+// between queues with runnable jobs by weight; within a queue jobs are
+// taken by priority, then age. Queues at their max_in_flight limit are
+// skipped.
+func (r *PostgresRepository) PickJobs(ctx context.Context, lockedBy string, queues []string, limit int, timeout time.Duration) ([]*models.Job, error) {
+	// READ COMMITTED so the in-flight count below sees jobs claimed by
+	// transactions that committed while we waited for the queue lock.
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	// Atomic job pickup with SKIP LOCKED
+	runnable, err := r.runnableQueues(ctx, tx, queues)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := pickBatch(runnable, limit, func(queue *models.Queue, limit int) ([]*models.Job, error) {
+		if queue.MaxInFlight > 0 {
+			free, err := r.freeInFlightSlots(ctx, tx, queue)
+			if err != nil {
+				return nil, err
+			}
+			limit = min(limit, free)
+			if limit <= 0 {
+				return nil, nil
+			}
+		}
+
+		return r.pickFromQueue(ctx, tx, lockedBy, queue.Name, limit, timeout)
+	})
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return jobs, tx.Commit()
+}
+
+// runnableQueues returns the unpaused queues, limited to subscribed when it
+// is not empty, that have runnable jobs, with their weights and limits.
+// Queues without a row in queues get defaults.
+func (r *PostgresRepository) runnableQueues(ctx context.Context, tx *sql.Tx, subscribed []string) ([]*models.Queue, error) {
 	query := `
-		SELECT id, queue, payload, max_retries, run_at, created_at, updated_at,
-		       status, priority, idempotency_key
-		FROM jobs
-		WHERE status = 'pending'
-		AND run_at <= NOW()
-		ORDER BY priority DESC, created_at ASC
-		FOR UPDATE SKIP LOCKED
-		LIMIT 1
+		SELECT j.queue, COALESCE(q.weight, 1), COALESCE(q.max_in_flight, 0)
+		FROM (
+			SELECT DISTINCT queue FROM jobs
+			WHERE status = 'pending'
+			AND run_at <= NOW()
+			AND (COALESCE(cardinality($1::text[]), 0) = 0 OR queue = ANY($1))
+		) j
+		LEFT JOIN queues q ON q.name = j.queue
+		WHERE COALESCE(q.paused, FALSE) = FALSE
 	`
 
-	var job models.Job
-	err = tx.QueryRowContext(ctx, query).Scan(
-		&job.ID, &job.Queue, &job.Payload, &job.MaxRetries, &job.RunAt,
-		&job.CreatedAt, &job.UpdatedAt, &job.Status, &job.Priority,
-		&job.IdempotencyKey,
-	)
+	rows, err := tx.QueryContext(ctx, query, pq.Array(subscribed))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
+	defer rows.Close()
 
-	// Update job status to processing and set lock
-	updateQuery := `
-		UPDATE jobs
-		SET status = 'processing', locked_by = $1, locked_at = NOW(), updated_at = NOW()
-		WHERE id = $2
-	`
+	var queues []*models.Queue
+	for rows.Next() {
+		var queue models.Queue
+		if err := rows.Scan(&queue.Name, &queue.Weight, &queue.MaxInFlight); err != nil {
+			return nil, err
+		}
+		queues = append(queues, &queue)
+	}
+
+	return queues, rows.Err()
+}
+
+// freeInFlightSlots takes the queue's cluster-wide advisory lock for the
+// rest of the transaction and reports how many more jobs may start. If
+// another node holds the lock the queue is skipped rather than waited on,
+// which also rules out lock-order deadlocks between pickers.
+func (r *PostgresRepository) freeInFlightSlots(ctx context.Context, tx *sql.Tx, queue *models.Queue) (int, error) {
+	var locked bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT pg_try_advisory_xact_lock(hashtext($1))`, "gosynq:queue:"+queue.Name,
+	).Scan(&locked)
+	if err != nil || !locked {
+		return 0, err
+	}
+
+	var inFlight int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM jobs WHERE queue = $1 AND status = 'processing'`, queue.Name,
+	).Scan(&inFlight)
+	if err != nil {
+		return 0, err
+	}
+
+	return queue.MaxInFlight - inFlight, nil
+}
 
-	_, err = tx.ExecContext(ctx, updateQuery, workerID, job.ID)
+func (r *PostgresRepository) pickFromQueue(ctx context.Context, tx *sql.Tx, lockedBy string, queue string, limit int, timeout time.Duration) ([]*models.Job, error) {
+	// Atomic job pickup with SKIP LOCKED; the lease runs out after timeout
+	query := `
+		WITH picked AS (
+			SELECT id AS picked_id FROM jobs
+			WHERE status = 'pending'
+			AND run_at <= NOW()
+			AND queue = $3
+			ORDER BY ` + priorityRankSQL + ` DESC, created_at ASC
+			FOR UPDATE SKIP LOCKED
+			LIMIT $4
+		)
+		UPDATE jobs
+		SET status = 'processing', locked_by = $1, locked_at = NOW(),
+		    lease_expires_at = NOW() + $2 * INTERVAL '1 millisecond', lease_token = lease_token + 1,
+		    attempts = attempts + 1, updated_at = NOW()
+		FROM picked
+		WHERE id = picked.picked_id
+		RETURNING ` + jobColumns
+
+	rows, err := tx.QueryContext(ctx, query, lockedBy, timeout.Milliseconds(), queue, limit)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
 
-	return &job, tx.Commit()
+	return jobs, rows.Err()
+}
+
+func (r *PostgresRepository) ExtendLease(ctx context.Context, jobID string, leaseToken int64, timeout time.Duration) error {
+	query := `
+		UPDATE jobs
+		SET locked_at = NOW(), lease_expires_at = NOW() + $1 * INTERVAL '1 millisecond', updated_at = NOW()
+		WHERE id = $2 AND lease_token = $3 AND status = 'processing'
+	`
+
+	return fenced(r.db.ExecContext(ctx, query, timeout.Milliseconds(), jobID, leaseToken))
+}
+
+func (r *PostgresRepository) FinishJob(ctx context.Context, jobID string, leaseToken int64, status models.JobStatus, lastError string) error {
+	query := `
+		UPDATE jobs
+		SET status = $1, last_error = NULLIF($4, ''), locked_by = NULL, locked_at = NULL, lease_expires_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $2 AND lease_token = $3 AND status = 'processing'
+	`
+
+	return fenced(r.db.ExecContext(ctx, query, status, jobID, leaseToken, lastError))
 }
 
 func (r *PostgresRepository) UpdateJobStatus(ctx context.Context, jobID string, status models.JobStatus) error {
 	query := `
 		UPDATE jobs
-		SET status = $1, locked_by = NULL, locked_at = NULL, updated_at = NOW()
+		SET status = $1, locked_by = NULL, locked_at = NULL, lease_expires_at = NULL, updated_at = NOW()
 		WHERE id = $2
 	`
 
@@ -130,44 +386,78 @@ func (r *PostgresRepository) UpdateJobStatus(ctx context.Context, jobID string,
 	return err
 }
 
-func (r *PostgresRepository) UpdateJobForRetry(ctx context.Context, jobID string, runAt time.Time) error {
+func (r *PostgresRepository) UpdateJobForRetry(ctx context.Context, jobID string, leaseToken int64, runAt time.Time, lastError string) error {
 	query := `
 		UPDATE jobs
-		SET status = 'pending', run_at = $1, locked_by = NULL, locked_at = NULL, updated_at = NOW()
-		WHERE id = $2
+		SET status = 'pending', run_at = $1, last_error = NULLIF($4, ''), locked_by = NULL, locked_at = NULL,
+		    lease_expires_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND lease_token = $3 AND status = 'processing'
 	`
 
-	_, err := r.db.ExecContext(ctx, query, runAt, jobID)
-	return err
+	return fenced(r.db.ExecContext(ctx, query, runAt, jobID, leaseToken, lastError))
+}
+
+func (r *PostgresRepository) SnoozeJob(ctx context.Context, jobID string, leaseToken int64, runAt time.Time) error {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', run_at = $1, snoozes = snoozes + 1, locked_by = NULL, locked_at = NULL,
+		    lease_expires_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND lease_token = $3 AND status = 'processing'
+	`
+
+	return fenced(r.db.ExecContext(ctx, query, runAt, jobID, leaseToken))
 }
 
 func (r *PostgresRepository) CreateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error {
+	return r.insertJobAttempt(ctx, r.db, attempt)
+}
+
+// insertJobAttempt numbers the attempt after the job's existing ones, so
+// numbering carries on across a requeue that resets the job's attempts.
+func (r *PostgresRepository) insertJobAttempt(ctx context.Context, q queryer, attempt *models.JobAttempt) error {
 	query := `
 		INSERT INTO job_attempts (
-			id, job_id, attempt_number, started_at, completed_at, status, error_message
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			id, job_id, attempt_number, started_at, completed_at, status, error_message, duration_ms,
+			worker_id
+		) VALUES (
+			$1, $2, (SELECT COALESCE(MAX(attempt_number), 0) + 1 FROM job_attempts WHERE job_id = $2),
+			$3, $4, $5, $6, $7, NULLIF($8, '')
+		)
+		RETURNING attempt_number
 	`
 
-	_, err := r.db.ExecContext(ctx,
+	return q.QueryRowContext(ctx,
 		query,
-		attempt.ID, attempt.JobID, attempt.AttemptNumber, attempt.StartedAt,
-		attempt.CompletedAt, attempt.Status, attempt.ErrorMessage,
+		attempt.ID, attempt.JobID, attempt.StartedAt,
+		attempt.CompletedAt, attempt.Status, attempt.ErrorMessage, attempt.DurationMs,
+		attempt.WorkerID,
+	).Scan(&attempt.AttemptNumber)
+}
+
+func (r *PostgresRepository) UpdateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error {
+	query := `
+		UPDATE job_attempts
+		SET completed_at = $1, status = $2, error_message = $3, duration_ms = $4
+		WHERE id = $5 AND status = 'processing'
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		attempt.CompletedAt, attempt.Status, attempt.ErrorMessage, attempt.DurationMs, attempt.ID,
 	)
 	return err
 }
 
-func (r *PostgresRepository) ListJobs(ctx context.Context, statusFilter string, queueFilter string, limit int) ([]*models.Job, error) {
+// ExpiredLeases returns processing jobs whose lease ran out, oldest first.
+func (r *PostgresRepository) ExpiredLeases(ctx context.Context, limit int) ([]*models.Job, error) {
 	query := `
-		SELECT id, queue, payload, max_retries, run_at, created_at, updated_at,
-		       status, priority, idempotency_key, locked_by, locked_at
+		SELECT ` + jobColumns + `
 		FROM jobs
-		WHERE ($1 = '' OR status = $1)
-		AND ($2 = '' OR queue = $2)
-		ORDER BY created_at DESC
-		LIMIT $3
+		WHERE status = 'processing' AND lease_expires_at <= NOW()
+		ORDER BY lease_expires_at ASC
+		LIMIT $1
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, statusFilter, queueFilter, limit)
+	rows, err := r.db.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -175,36 +465,100 @@ func (r *PostgresRepository) ListJobs(ctx context.Context, statusFilter string,
 
 	var jobs []*models.Job
 	for rows.Next() {
-		var job models.Job
-		var lockedBy sql.NullString
-		var lockedAt pq.NullTime
-
-		err := rows.Scan(
-			&job.ID, &job.Queue, &job.Payload, &job.MaxRetries, &job.RunAt,
-			&job.CreatedAt, &job.UpdatedAt, &job.Status, &job.Priority,
-			&job.IdempotencyKey, &lockedBy, &lockedAt,
-		)
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, err
 		}
+		jobs = append(jobs, job)
+	}
 
-		if lockedBy.Valid {
-			job.LockedBy = lockedBy.String
-		}
+	return jobs, rows.Err()
+}
 
-		if lockedAt.Valid {
-			job.LockedAt = &lockedAt.Time
-		}
+func (r *PostgresRepository) AbandonJob(ctx context.Context, job *models.Job, status models.JobStatus, attempt *models.JobAttempt) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
 
-		jobs = append(jobs, &job)
+	query := `
+		UPDATE jobs
+		SET status = $1, run_at = CASE WHEN $1 = 'pending' THEN NOW() ELSE run_at END, last_error = $4,
+		    locked_by = NULL, locked_at = NULL, lease_expires_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND lease_token = $3 AND status = 'processing' AND lease_expires_at <= NOW()
+	`
+
+	result, err := tx.ExecContext(ctx, query, status, job.ID, job.LeaseToken, attempt.ErrorMessage)
+	if err != nil {
+		return false, err
+	}
+	reclaimed, err := result.RowsAffected()
+	if err != nil || reclaimed == 0 {
+		return false, err
 	}
 
-	return jobs, nil
+	// The worker may have recorded the start of this attempt before dying.
+	// Earlier attempts are all finished, so that is the only one running.
+	attemptQuery := `
+		UPDATE job_attempts
+		SET completed_at = $1, status = $2, error_message = $3, duration_ms = $4
+		WHERE job_id = $5 AND status = 'processing'
+		RETURNING attempt_number
+	`
+
+	err = tx.QueryRowContext(ctx, attemptQuery,
+		attempt.CompletedAt, attempt.Status, attempt.ErrorMessage, attempt.DurationMs, attempt.JobID,
+	).Scan(&attempt.AttemptNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = r.insertJobAttempt(ctx, tx, attempt)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *PostgresRepository) ListJobs(ctx context.Context, filter JobFilter) ([]*models.Job, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE ($1 = '' OR status = $1)
+		AND ($2 = '' OR queue = $2)
+		AND ($3 = '' OR type = $3)
+		AND (NOT $5 OR run_at > NOW())
+		ORDER BY CASE WHEN $5 THEN run_at END ASC, created_at DESC
+		LIMIT $4
+	`
+
+	// LIMIT NULL returns every row
+	var limit any
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, filter.Status, filter.Queue, filter.Type, limit, filter.Scheduled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
 }
 
 func (r *PostgresRepository) GetJobAttempts(ctx context.Context, jobID string) ([]*models.JobAttempt, error) {
 	query := `
-		SELECT id, job_id, attempt_number, started_at, completed_at, status, error_message
+		SELECT ` + attemptColumns + `
 		FROM job_attempts
 		WHERE job_id = $1
 		ORDER BY attempt_number ASC
@@ -218,25 +572,14 @@ func (r *PostgresRepository) GetJobAttempts(ctx context.Context, jobID string) (
 
 	var attempts []*models.JobAttempt
 	for rows.Next() {
-		var attempt models.JobAttempt
-		var completedAt pq.NullTime
-
-		err := rows.Scan(
-			&attempt.ID, &attempt.JobID, &attempt.AttemptNumber, &attempt.StartedAt,
-			&completedAt, &attempt.Status, &attempt.ErrorMessage,
-		)
+		attempt, err := scanJobAttempt(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		if completedAt.Valid {
-			attempt.CompletedAt = &completedAt.Time
-		}
-
-		attempts = append(attempts, &attempt)
+		attempts = append(attempts, attempt)
 	}
 
-	return attempts, nil
+	return attempts, rows.Err()
 }
 
 func (r *PostgresRepository) GetJobStats(ctx context.Context) (map[string]int, error) {
@@ -262,5 +605,243 @@ func (r *PostgresRepository) GetJobStats(ctx context.Context) (map[string]int, e
 		stats[status] = count
 	}
 
-	return stats, nil
+	return stats, rows.Err()
+}
+
+func (r *PostgresRepository) SetQueuePaused(ctx context.Context, queue string, paused bool) error {
+	query := `
+		INSERT INTO queues (name, paused, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET paused = EXCLUDED.paused, updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, queue, paused)
+	return err
+}
+
+func (r *PostgresRepository) ConfigureQueue(ctx context.Context, queue string, weight int, maxInFlight int) error {
+	query := `
+		INSERT INTO queues (name, weight, max_in_flight, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET weight = EXCLUDED.weight, max_in_flight = EXCLUDED.max_in_flight, updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, queue, weight, maxInFlight)
+	return err
+}
+
+func (r *PostgresRepository) SetQueueRetryPolicy(ctx context.Context, queue string, policy *models.RetryPolicy) error {
+	query := `
+		INSERT INTO queues (name, retry_policy, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET retry_policy = EXCLUDED.retry_policy, updated_at = NOW()
+	`
+
+	retryPolicy, err := encodeRetryPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, queue, retryPolicy)
+	return err
+}
+
+func (r *PostgresRepository) GetQueue(ctx context.Context, name string) (*models.Queue, error) {
+	query := `SELECT ` + queueColumns + ` FROM queues WHERE name = $1`
+
+	queue, err := scanQueue(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return queue, err
+}
+
+func (r *PostgresRepository) ListQueues(ctx context.Context) ([]*models.Queue, error) {
+	query := `
+		SELECT ` + queueColumns + `
+		FROM queues
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queues []*models.Queue
+	for rows.Next() {
+		queue, err := scanQueue(rows)
+		if err != nil {
+			return nil, err
+		}
+		queues = append(queues, queue)
+	}
+
+	return queues, rows.Err()
+}
+
+// deadJobsWhere selects the dead jobs matched by a DeadJobFilter passed as
+// $1 (queue) and $2 (ids).
+const deadJobsWhere = `
+		WHERE status = 'dead'
+		AND ($1 = '' OR queue = $1)
+		AND (COALESCE(cardinality($2::text[]), 0) = 0 OR id::text = ANY($2))
+	`
+
+// RequeueDeadJobs returns matching dead jobs to pending to run now, with
+// their attempts, snoozes and last error reset.
+func (r *PostgresRepository) RequeueDeadJobs(ctx context.Context, filter DeadJobFilter) (int, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', run_at = NOW(), attempts = 0, snoozes = 0, last_error = NULL,
+		    updated_at = NOW()
+	` + deadJobsWhere
+
+	result, err := r.db.ExecContext(ctx, query, filter.Queue, pq.Array(filter.IDs))
+	if err != nil {
+		return 0, err
+	}
+	requeued, err := result.RowsAffected()
+	return int(requeued), err
+}
+
+// PurgeDeadJobs deletes matching dead jobs along with their attempts.
+func (r *PostgresRepository) PurgeDeadJobs(ctx context.Context, filter DeadJobFilter) (int, error) {
+	query := `DELETE FROM jobs` + deadJobsWhere
+
+	result, err := r.db.ExecContext(ctx, query, filter.Queue, pq.Array(filter.IDs))
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
+
+func (r *PostgresRepository) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	query := `
+		INSERT INTO schedules (
+			id, name, cron, timezone, queue, type, payload, priority, max_retries,
+			enabled, next_run_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at, updated_at
+	`
+
+	return r.db.QueryRowContext(ctx,
+		query,
+		schedule.ID, schedule.Name, schedule.Cron, schedule.Timezone, schedule.Queue,
+		schedule.Type, schedule.Payload, schedule.Priority, schedule.MaxRetries,
+		schedule.Enabled, schedule.NextRunAt,
+	).Scan(&schedule.CreatedAt, &schedule.UpdatedAt)
+}
+
+func (r *PostgresRepository) GetSchedule(ctx context.Context, id string) (*models.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = $1`
+
+	schedule, err := scanSchedule(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (r *PostgresRepository) ListSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules ORDER BY created_at ASC`
+
+	return r.querySchedules(ctx, query)
+}
+
+func (r *PostgresRepository) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	query := `
+		UPDATE schedules
+		SET name = $1, cron = $2, timezone = $3, queue = $4, type = $5, payload = $6,
+		    priority = $7, max_retries = $8, enabled = $9, next_run_at = $10, updated_at = NOW()
+		WHERE id = $11
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		schedule.Name, schedule.Cron, schedule.Timezone, schedule.Queue, schedule.Type,
+		schedule.Payload, schedule.Priority, schedule.MaxRetries, schedule.Enabled,
+		schedule.NextRunAt, schedule.ID,
+	).Scan(&schedule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+func (r *PostgresRepository) DeleteSchedule(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresRepository) DueSchedules(ctx context.Context, now time.Time) ([]*models.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE enabled AND next_run_at <= $1
+		ORDER BY next_run_at ASC
+	`
+
+	return r.querySchedules(ctx, query, now)
+}
+
+// FireSchedule advances the schedule with a compare-and-swap on next_run_at
+// and inserts the job in the same transaction, so a tick produces exactly
+// one job however many nodes race for it.
+func (r *PostgresRepository) FireSchedule(ctx context.Context, scheduleID string, runAt, nextRunAt time.Time, job *models.Job) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE schedules
+		SET next_run_at = $1, last_run_at = $2, updated_at = NOW()
+		WHERE id = $3 AND enabled AND next_run_at = $2
+	`
+
+	result, err := tx.ExecContext(ctx, query, nextRunAt, runAt, scheduleID)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil || claimed == 0 {
+		return false, err
+	}
+
+	if _, err := r.insertJob(ctx, tx, job); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *PostgresRepository) querySchedules(ctx context.Context, query string, args ...any) ([]*models.Schedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*models.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
 }
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"math/rand/v2"
+	"sort"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+// priorityRankSQL orders the priority column high > normal > low in SQL.
+const priorityRankSQL = `CASE priority WHEN 'high' THEN 2 WHEN 'low' THEN 0 ELSE 1 END`
+
+// pickBatch claims up to n jobs from queues through pick, which claims up
+// to limit jobs from one queue. The batch is split between the queues at
+// random in proportion to their weights, as n single picks would be;
+// whatever a queue cannot fill is then offered to the others in weighted
+// random order.
+func pickBatch(queues []*models.Queue, n int, pick func(queue *models.Queue, limit int) ([]*models.Job, error)) ([]*models.Job, error) {
+	if len(queues) == 0 {
+		return nil, nil
+	}
+
+	order := weightedQueueOrder(queues)
+	shares := weightedShares(queues, n)
+	drained := make(map[string]bool)
+
+	var picked []*models.Job
+	for _, byShare := range []bool{true, false} {
+		for _, queue := range order {
+			limit := n - len(picked)
+			if byShare {
+				limit = min(limit, shares[queue.Name])
+			}
+			if limit == 0 || drained[queue.Name] {
+				continue
+			}
+
+			jobs, err := pick(queue, limit)
+			if err != nil {
+				return nil, err
+			}
+			if len(jobs) < limit {
+				drained[queue.Name] = true
+			}
+			sortForPickup(jobs)
+			picked = append(picked, jobs...)
+		}
+	}
+
+	return picked, nil
+}
+
+// weightedShares deals n pickups out to queues one at a time, each going
+// to a queue chosen at random in proportion to its weight.
+func weightedShares(queues []*models.Queue, n int) map[string]int {
+	total := 0
+	for _, q := range queues {
+		total += queueWeight(q)
+	}
+
+	shares := make(map[string]int, len(queues))
+	for range n {
+		r := rand.IntN(total)
+		for _, q := range queues {
+			r -= queueWeight(q)
+			if r < 0 {
+				shares[q.Name]++
+				break
+			}
+		}
+	}
+
+	return shares
+}
+
+// weightedQueueOrder returns the order in which PickJobs tries queues: a
+// weighted random permutation, so a queue with weight 6 is tried before a
+// queue with weight 1 six times out of seven. Every queue is still tried,
+// so a light queue is only delayed, never starved.
+func weightedQueueOrder(queues []*models.Queue) []*models.Queue {
+	remaining := append([]*models.Queue(nil), queues...)
+	ordered := make([]*models.Queue, 0, len(queues))
+
+	for len(remaining) > 0 {
+		total := 0
+		for _, q := range remaining {
+			total += queueWeight(q)
+		}
+
+		n := rand.IntN(total)
+		for i, q := range remaining {
+			n -= queueWeight(q)
+			if n < 0 {
+				ordered = append(ordered, q)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return ordered
+}
+
+func queueWeight(q *models.Queue) int {
+	if q.Weight < 1 {
+		return 1
+	}
+	return q.Weight
+}
+
+// pickBefore reports whether a should be picked ahead of b: higher
+// priority first, then oldest first.
+func pickBefore(a, b *models.Job) bool {
+	if pa, pb := priorityRank(a.Priority), priorityRank(b.Priority); pa != pb {
+		return pa > pb
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+func priorityRank(p models.JobPriority) int {
+	switch p {
+	case models.PriorityHigh:
+		return 2
+	case models.PriorityLow:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// sortForPickup puts jobs claimed from one queue back in pickup order,
+// which UPDATE ... RETURNING does not keep.
+func sortForPickup(jobs []*models.Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return pickBefore(jobs[i], jobs[j])
+	})
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+// supply returns a pick function that hands out up to available jobs per
+// queue and counts what it gave each one.
+func supply(available map[string]int, picked map[string]int) func(*models.Queue, int) ([]*models.Job, error) {
+	return func(queue *models.Queue, limit int) ([]*models.Job, error) {
+		n := limit
+		if have, ok := available[queue.Name]; ok {
+			n = min(n, have-picked[queue.Name])
+		}
+		jobs := make([]*models.Job, n)
+		for i := range jobs {
+			jobs[i] = &models.Job{ID: uuid.New().String(), Queue: queue.Name}
+		}
+		picked[queue.Name] += n
+		return jobs, nil
+	}
+}
+
+func TestPickBatchWeights(t *testing.T) {
+	queues := []*models.Queue{{Name: "heavy", Weight: 3}, {Name: "light", Weight: 1}}
+	picked := make(map[string]int)
+	pick := supply(nil, picked)
+
+	const rounds = 4000
+	for range rounds {
+		jobs, err := pickBatch(queues, 1, pick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(jobs) != 1 {
+			t.Fatalf("pickBatch() picked %d jobs, want 1", len(jobs))
+		}
+	}
+
+	share := float64(picked["heavy"]) / rounds
+	if share < 0.70 || share > 0.80 {
+		t.Errorf("heavy queue got %.2f of pickups, want about 0.75", share)
+	}
+	if picked["light"] == 0 {
+		t.Error("light queue was starved")
+	}
+}
+
+func TestPickBatchFillsFromOtherQueues(t *testing.T) {
+	queues := []*models.Queue{{Name: "heavy", Weight: 10}, {Name: "light", Weight: 1}}
+	picked := make(map[string]int)
+	pick := supply(map[string]int{"heavy": 1}, picked)
+
+	jobs, err := pickBatch(queues, 10, pick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 10 || picked["heavy"] != 1 || picked["light"] != 9 {
+		t.Errorf("pickBatch() picked %v, want 1 heavy and 9 light", picked)
+	}
+}
+
+func TestPickBatchZeroWeight(t *testing.T) {
+	queues := []*models.Queue{{Name: "unset"}}
+	jobs, err := pickBatch(queues, 5, supply(nil, make(map[string]int)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 5 {
+		t.Errorf("pickBatch() picked %d jobs from a queue without a weight, want 5", len(jobs))
+	}
+}
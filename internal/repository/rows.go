@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+// The column lists below are shared by the SQL backends and read with the
+// matching scan function.
+
+const jobColumns = `id, queue, type, payload, max_retries, run_at, created_at, updated_at,
+		       status, priority, COALESCE(idempotency_key, ''), locked_by, locked_at,
+		       lease_expires_at, lease_token, COALESCE(last_error, ''),
+		       attempts, retry_policy, snoozes`
+
+// insertBatchSize is the most jobs CreateJobs puts in one INSERT statement,
+// keeping the bound parameters well under the drivers' limits.
+const insertBatchSize = 500
+
+// idempotencyKeysByQueue groups the idempotency keys of jobs by queue.
+func idempotencyKeysByQueue(jobs []*models.Job) map[string][]string {
+	keys := make(map[string][]string)
+	for _, job := range jobs {
+		if job.IdempotencyKey != "" {
+			keys[job.Queue] = append(keys[job.Queue], job.IdempotencyKey)
+		}
+	}
+	return keys
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row rowScanner) (*models.Job, error) {
+	var job models.Job
+	var payload []byte
+	var lockedBy sql.NullString
+	var lockedAt, leaseExpiresAt sql.NullTime
+	var retryPolicy []byte
+
+	err := row.Scan(
+		&job.ID, &job.Queue, &job.Type, &payload, &job.MaxRetries, &job.RunAt,
+		&job.CreatedAt, &job.UpdatedAt, &job.Status, &job.Priority,
+		&job.IdempotencyKey, &lockedBy, &lockedAt, &leaseExpiresAt, &job.LeaseToken,
+		&job.LastError, &job.Attempts, &retryPolicy, &job.Snoozes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Payload = payload
+
+	if lockedBy.Valid {
+		job.LockedBy = lockedBy.String
+	}
+
+	if lockedAt.Valid {
+		job.LockedAt = &lockedAt.Time
+	}
+
+	if leaseExpiresAt.Valid {
+		job.LeaseExpiresAt = &leaseExpiresAt.Time
+	}
+
+	job.RetryPolicy, err = decodeRetryPolicy(retryPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// encodeRetryPolicy returns the value stored in a retry_policy column:
+// JSON text, or NULL when there is no policy.
+func encodeRetryPolicy(policy *models.RetryPolicy) (any, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func decodeRetryPolicy(data []byte) (*models.RetryPolicy, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var policy models.RetryPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to decode retry policy: %w", err)
+	}
+	return &policy, nil
+}
+
+const queueColumns = `name, paused, weight, max_in_flight, retry_policy, updated_at`
+
+func scanQueue(row rowScanner) (*models.Queue, error) {
+	var queue models.Queue
+	var retryPolicy []byte
+
+	err := row.Scan(&queue.Name, &queue.Paused, &queue.Weight, &queue.MaxInFlight, &retryPolicy, &queue.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	queue.RetryPolicy, err = decodeRetryPolicy(retryPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &queue, nil
+}
+
+const attemptColumns = `id, job_id, attempt_number, started_at, completed_at, status,
+		       COALESCE(error_message, ''), COALESCE(duration_ms, 0), COALESCE(worker_id, '')`
+
+func scanJobAttempt(row rowScanner) (*models.JobAttempt, error) {
+	var attempt models.JobAttempt
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&attempt.ID, &attempt.JobID, &attempt.AttemptNumber, &attempt.StartedAt,
+		&completedAt, &attempt.Status, &attempt.ErrorMessage, &attempt.DurationMs,
+		&attempt.WorkerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if completedAt.Valid {
+		attempt.CompletedAt = &completedAt.Time
+	}
+
+	return &attempt, nil
+}
+
+const scheduleColumns = `id, name, cron, timezone, queue, type, payload, priority, max_retries,
+		       enabled, next_run_at, last_run_at, created_at, updated_at`
+
+func scanSchedule(row rowScanner) (*models.Schedule, error) {
+	var schedule models.Schedule
+	var payload []byte
+	var lastRunAt sql.NullTime
+
+	err := row.Scan(
+		&schedule.ID, &schedule.Name, &schedule.Cron, &schedule.Timezone, &schedule.Queue,
+		&schedule.Type, &payload, &schedule.Priority, &schedule.MaxRetries, &schedule.Enabled,
+		&schedule.NextRunAt, &lastRunAt, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Payload = payload
+
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+
+	return &schedule, nil
+}
@@ -0,0 +1,977 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteRepository is a Store backed by a single SQLite database file. It
+// suits small deployments and CI where running PostgreSQL is overkill.
+//
+// All timestamps are written from Go in UTC so that they compare correctly
+// as text; queries never rely on SQLite's own clock.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+// OpenSQLite opens the SQLite database at path, creating it if needed.
+// SQLite allows a single writer, so the pool is limited to one connection;
+// this also keeps ":memory:" databases consistent across queries.
+// Transactions begin IMMEDIATE so that processes sharing the file serialize
+// on the write lock up front instead of failing on upgrade.
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate", path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// Migrate applies every embedded SQLite migration that has not been
+// applied yet.
+func (r *SQLiteRepository) Migrate(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(migrations.SQLite, "sqlite/*.up.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := strings.TrimSuffix(strings.TrimPrefix(file, "sqlite/"), ".up.sql")
+		if err := r.applyMigration(ctx, version, file); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) applyMigration(ctx context.Context, version, file string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	script, err := fs.ReadFile(migrations.SQLite, file)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+		version, time.Now().UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) CreateJob(ctx context.Context, job *models.Job, releaseBefore time.Time) (*models.Job, error) {
+	if releaseBefore.IsZero() || job.IdempotencyKey == "" {
+		return r.insertJob(ctx, r.db, job)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := r.releaseIdempotencyKeys(ctx, tx, job.Queue, []string{job.IdempotencyKey}, releaseBefore); err != nil {
+		return nil, err
+	}
+	existing, err := r.insertJob(ctx, tx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, tx.Commit()
+}
+
+func (r *SQLiteRepository) insertJob(ctx context.Context, q queryer, job *models.Job) (*models.Job, error) {
+	query := `
+		INSERT INTO jobs (
+			id, queue, type, payload, max_retries, run_at, created_at, updated_at,
+			priority, idempotency_key, retry_policy
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)
+		ON CONFLICT (queue, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+	`
+
+	retryPolicy, err := encodeRetryPolicy(job.RetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	runAt := job.RunAt
+	if runAt.IsZero() {
+		runAt = now
+	}
+
+	result, err := q.ExecContext(ctx,
+		query,
+		job.ID, job.Queue, job.Type, []byte(job.Payload), job.MaxRetries, runAt.UTC(),
+		now, now, job.Priority, job.IdempotencyKey, retryPolicy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if inserted > 0 {
+		job.CreatedAt = now
+		job.UpdatedAt = now
+		return nil, nil
+	}
+
+	// The idempotency key is taken
+	query = `SELECT ` + jobColumns + ` FROM jobs WHERE queue = ? AND idempotency_key = ?`
+
+	existing, err := scanJob(q.QueryRowContext(ctx, query, job.Queue, job.IdempotencyKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// CreateJobs inserts jobs with multi-row INSERTs of up to insertBatchSize
+// rows each, all in one transaction.
+func (r *SQLiteRepository) CreateJobs(ctx context.Context, jobs []*models.Job, releaseBefore time.Time) ([]*models.Job, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if !releaseBefore.IsZero() {
+		for queue, keys := range idempotencyKeysByQueue(jobs) {
+			if err := r.releaseIdempotencyKeys(ctx, tx, queue, keys, releaseBefore); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	existing := make([]*models.Job, len(jobs))
+	for start := 0; start < len(jobs); start += insertBatchSize {
+		end := min(start+insertBatchSize, len(jobs))
+		if err := r.insertJobBatch(ctx, tx, jobs[start:end], existing[start:end], now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// insertJobBatch inserts jobs in a single statement and fills existing
+// with the key holder of every job that was skipped.
+func (r *SQLiteRepository) insertJobBatch(ctx context.Context, tx *sql.Tx, jobs []*models.Job, existing []*models.Job, now time.Time) error {
+	placeholders := make([]string, len(jobs))
+	args := make([]any, 0, len(jobs)*11)
+	for i, job := range jobs {
+		retryPolicy, err := encodeRetryPolicy(job.RetryPolicy)
+		if err != nil {
+			return err
+		}
+
+		runAt := job.RunAt
+		if runAt.IsZero() {
+			runAt = now
+		}
+
+		placeholders[i] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)"
+		args = append(args,
+			job.ID, job.Queue, job.Type, []byte(job.Payload), job.MaxRetries, runAt.UTC(),
+			now, now, job.Priority, job.IdempotencyKey, retryPolicy,
+		)
+	}
+
+	query := `
+		INSERT INTO jobs (
+			id, queue, type, payload, max_retries, run_at, created_at, updated_at,
+			priority, idempotency_key, retry_policy
+		) VALUES ` + strings.Join(placeholders, ", ") + `
+		ON CONFLICT (queue, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+		RETURNING id
+	`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	inserted := make(map[string]bool, len(jobs))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		inserted[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	// The rest lost their idempotency key to an existing job
+	query = `SELECT ` + jobColumns + ` FROM jobs WHERE queue = ? AND idempotency_key = ?`
+	for i, job := range jobs {
+		if inserted[job.ID] {
+			job.CreatedAt = now
+			job.UpdatedAt = now
+			continue
+		}
+		holder, err := scanJob(tx.QueryRowContext(ctx, query, job.Queue, job.IdempotencyKey))
+		if err != nil {
+			return err
+		}
+		existing[i] = holder
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) GetJobByID(ctx context.Context, id string) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = ?`
+
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// releaseIdempotencyKeys frees keys in queue that are held by jobs
+// created before createdBefore, so new jobs may claim them.
+func (r *SQLiteRepository) releaseIdempotencyKeys(ctx context.Context, q queryer, queue string, keys []string, createdBefore time.Time) error {
+	for start := 0; start < len(keys); start += insertBatchSize {
+		chunk := keys[start:min(start+insertBatchSize, len(keys))]
+
+		query := `
+			UPDATE jobs
+			SET idempotency_key = NULL
+			WHERE queue = ? AND created_at < ?
+			  AND idempotency_key IN (?` + strings.Repeat(", ?", len(chunk)-1) + `)
+		`
+
+		args := []any{queue, createdBefore.UTC()}
+		for _, key := range chunk {
+			args = append(args, key)
+		}
+
+		if _, err := q.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PickJobs claims up to limit runnable jobs from the given queues, or from
+// any queue when queues is empty, sharing the batch between queues by
+// weight and skipping queues at their max_in_flight limit. SQLite has no
+// row locks; the transaction holds the database-wide write lock instead, so
+// no two pickers can claim the same row or overrun a limit.
+func (r *SQLiteRepository) PickJobs(ctx context.Context, lockedBy string, queues []string, limit int, timeout time.Duration) ([]*models.Job, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	runnable, err := r.runnableQueues(ctx, tx, queues, now)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := pickBatch(runnable, limit, func(queue *models.Queue, limit int) ([]*models.Job, error) {
+		if queue.MaxInFlight > 0 {
+			var inFlight int
+			err := tx.QueryRowContext(ctx,
+				`SELECT COUNT(*) FROM jobs WHERE queue = ? AND status = 'processing'`, queue.Name,
+			).Scan(&inFlight)
+			if err != nil {
+				return nil, err
+			}
+			limit = min(limit, queue.MaxInFlight-inFlight)
+			if limit <= 0 {
+				return nil, nil
+			}
+		}
+
+		return r.pickFromQueue(ctx, tx, lockedBy, queue.Name, limit, timeout, now)
+	})
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return jobs, tx.Commit()
+}
+
+func (r *SQLiteRepository) pickFromQueue(ctx context.Context, tx *sql.Tx, lockedBy string, queue string, limit int, timeout time.Duration, now time.Time) ([]*models.Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'processing', locked_by = ?, locked_at = ?, lease_expires_at = ?,
+		    lease_token = lease_token + 1, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE status = 'pending'
+			AND run_at <= ?
+			AND queue = ?
+			ORDER BY ` + priorityRankSQL + ` DESC, created_at ASC
+			LIMIT ?
+		)
+		RETURNING ` + jobColumns
+
+	rows, err := tx.QueryContext(ctx, query, lockedBy, now, now.Add(timeout), now, now, queue, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// runnableQueues returns the unpaused queues, limited to subscribed when it
+// is not empty, that have runnable jobs, with their weights and limits.
+// Queues without a row in queues get defaults.
+func (r *SQLiteRepository) runnableQueues(ctx context.Context, tx *sql.Tx, subscribed []string, now time.Time) ([]*models.Queue, error) {
+	query := `
+		SELECT j.queue, COALESCE(q.weight, 1), COALESCE(q.max_in_flight, 0)
+		FROM (
+			SELECT DISTINCT queue FROM jobs
+			WHERE status = 'pending'
+			AND run_at <= ?1
+			AND (json_array_length(?2) = 0 OR queue IN (SELECT value FROM json_each(?2)))
+		) j
+		LEFT JOIN queues q ON q.name = j.queue
+		WHERE COALESCE(q.paused, FALSE) = FALSE
+	`
+
+	filter, err := json.Marshal(subscribed)
+	if err != nil {
+		return nil, err
+	}
+	if subscribed == nil {
+		filter = []byte("[]")
+	}
+
+	rows, err := tx.QueryContext(ctx, query, now, string(filter))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queues []*models.Queue
+	for rows.Next() {
+		var queue models.Queue
+		if err := rows.Scan(&queue.Name, &queue.Weight, &queue.MaxInFlight); err != nil {
+			return nil, err
+		}
+		queues = append(queues, &queue)
+	}
+
+	return queues, rows.Err()
+}
+
+func (r *SQLiteRepository) ExtendLease(ctx context.Context, jobID string, leaseToken int64, timeout time.Duration) error {
+	query := `
+		UPDATE jobs
+		SET locked_at = ?1, lease_expires_at = ?2, updated_at = ?1
+		WHERE id = ?3 AND lease_token = ?4 AND status = 'processing'
+	`
+
+	now := time.Now().UTC()
+
+	return fenced(r.db.ExecContext(ctx, query, now, now.Add(timeout), jobID, leaseToken))
+}
+
+func (r *SQLiteRepository) FinishJob(ctx context.Context, jobID string, leaseToken int64, status models.JobStatus, lastError string) error {
+	query := `
+		UPDATE jobs
+		SET status = ?, last_error = NULLIF(?, ''), locked_by = NULL, locked_at = NULL, lease_expires_at = NULL,
+		    updated_at = ?
+		WHERE id = ? AND lease_token = ? AND status = 'processing'
+	`
+
+	return fenced(r.db.ExecContext(ctx, query, status, lastError, time.Now().UTC(), jobID, leaseToken))
+}
+
+func (r *SQLiteRepository) UpdateJobStatus(ctx context.Context, jobID string, status models.JobStatus) error {
+	query := `
+		UPDATE jobs
+		SET status = ?, locked_by = NULL, locked_at = NULL, lease_expires_at = NULL, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, status, time.Now().UTC(), jobID)
+	return err
+}
+
+func (r *SQLiteRepository) UpdateJobForRetry(ctx context.Context, jobID string, leaseToken int64, runAt time.Time, lastError string) error {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', run_at = ?, last_error = NULLIF(?, ''), locked_by = NULL, locked_at = NULL,
+		    lease_expires_at = NULL, updated_at = ?
+		WHERE id = ? AND lease_token = ? AND status = 'processing'
+	`
+
+	return fenced(r.db.ExecContext(ctx, query, runAt.UTC(), lastError, time.Now().UTC(), jobID, leaseToken))
+}
+
+func (r *SQLiteRepository) SnoozeJob(ctx context.Context, jobID string, leaseToken int64, runAt time.Time) error {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', run_at = ?, snoozes = snoozes + 1, locked_by = NULL, locked_at = NULL,
+		    lease_expires_at = NULL, updated_at = ?
+		WHERE id = ? AND lease_token = ? AND status = 'processing'
+	`
+
+	return fenced(r.db.ExecContext(ctx, query, runAt.UTC(), time.Now().UTC(), jobID, leaseToken))
+}
+
+func (r *SQLiteRepository) CreateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error {
+	return r.insertJobAttempt(ctx, r.db, attempt)
+}
+
+// insertJobAttempt numbers the attempt after the job's existing ones, so
+// numbering carries on across a requeue that resets the job's attempts.
+func (r *SQLiteRepository) insertJobAttempt(ctx context.Context, q queryer, attempt *models.JobAttempt) error {
+	query := `
+		INSERT INTO job_attempts (
+			id, job_id, attempt_number, started_at, completed_at, status, error_message, duration_ms,
+			worker_id
+		) VALUES (
+			?1, ?2, (SELECT COALESCE(MAX(attempt_number), 0) + 1 FROM job_attempts WHERE job_id = ?2),
+			?3, ?4, ?5, ?6, ?7, NULLIF(?8, '')
+		)
+		RETURNING attempt_number
+	`
+
+	return q.QueryRowContext(ctx,
+		query,
+		attempt.ID, attempt.JobID, attempt.StartedAt.UTC(),
+		nullTimeUTC(attempt.CompletedAt), attempt.Status, attempt.ErrorMessage, attempt.DurationMs,
+		attempt.WorkerID,
+	).Scan(&attempt.AttemptNumber)
+}
+
+func (r *SQLiteRepository) UpdateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error {
+	query := `
+		UPDATE job_attempts
+		SET completed_at = ?, status = ?, error_message = ?, duration_ms = ?
+		WHERE id = ? AND status = 'processing'
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		nullTimeUTC(attempt.CompletedAt), attempt.Status, attempt.ErrorMessage, attempt.DurationMs, attempt.ID,
+	)
+	return err
+}
+
+// nullTimeUTC converts an optional time for storage.
+func nullTimeUTC(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// ExpiredLeases returns processing jobs whose lease ran out, oldest first.
+func (r *SQLiteRepository) ExpiredLeases(ctx context.Context, limit int) ([]*models.Job, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE status = 'processing' AND lease_expires_at <= ?
+		ORDER BY lease_expires_at ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now().UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (r *SQLiteRepository) AbandonJob(ctx context.Context, job *models.Job, status models.JobStatus, attempt *models.JobAttempt) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE jobs
+		SET status = ?1, run_at = CASE WHEN ?1 = 'pending' THEN ?2 ELSE run_at END, last_error = ?5,
+		    locked_by = NULL, locked_at = NULL, lease_expires_at = NULL, updated_at = ?2
+		WHERE id = ?3 AND lease_token = ?4 AND status = 'processing' AND lease_expires_at <= ?2
+	`
+
+	result, err := tx.ExecContext(ctx, query, status, time.Now().UTC(), job.ID, job.LeaseToken, attempt.ErrorMessage)
+	if err != nil {
+		return false, err
+	}
+	reclaimed, err := result.RowsAffected()
+	if err != nil || reclaimed == 0 {
+		return false, err
+	}
+
+	// The worker may have recorded the start of this attempt before dying.
+	// Earlier attempts are all finished, so that is the only one running.
+	attemptQuery := `
+		UPDATE job_attempts
+		SET completed_at = ?, status = ?, error_message = ?, duration_ms = ?
+		WHERE job_id = ? AND status = 'processing'
+		RETURNING attempt_number
+	`
+
+	err = tx.QueryRowContext(ctx, attemptQuery,
+		nullTimeUTC(attempt.CompletedAt), attempt.Status, attempt.ErrorMessage, attempt.DurationMs, attempt.JobID,
+	).Scan(&attempt.AttemptNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = r.insertJobAttempt(ctx, tx, attempt)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *SQLiteRepository) ListJobs(ctx context.Context, filter JobFilter) ([]*models.Job, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE (?1 = '' OR status = ?1)
+		AND (?2 = '' OR queue = ?2)
+		AND (?3 = '' OR type = ?3)
+		AND (NOT ?5 OR run_at > ?6)
+		ORDER BY CASE WHEN ?5 THEN run_at END ASC, created_at DESC
+		LIMIT ?4
+	`
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, filter.Status, filter.Queue, filter.Type, limit, filter.Scheduled, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (r *SQLiteRepository) GetJobAttempts(ctx context.Context, jobID string) ([]*models.JobAttempt, error) {
+	query := `
+		SELECT ` + attemptColumns + `
+		FROM job_attempts
+		WHERE job_id = ?
+		ORDER BY attempt_number ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*models.JobAttempt
+	for rows.Next() {
+		attempt, err := scanJobAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+func (r *SQLiteRepository) GetJobStats(ctx context.Context) (map[string]int, error) {
+	query := `
+		SELECT status, COUNT(*)
+		FROM jobs
+		GROUP BY status
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		stats[status] = count
+	}
+
+	return stats, rows.Err()
+}
+
+func (r *SQLiteRepository) SetQueuePaused(ctx context.Context, queue string, paused bool) error {
+	query := `
+		INSERT INTO queues (name, paused, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE
+		SET paused = excluded.paused, updated_at = excluded.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, queue, paused, time.Now().UTC())
+	return err
+}
+
+func (r *SQLiteRepository) ConfigureQueue(ctx context.Context, queue string, weight int, maxInFlight int) error {
+	query := `
+		INSERT INTO queues (name, weight, max_in_flight, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE
+		SET weight = excluded.weight, max_in_flight = excluded.max_in_flight, updated_at = excluded.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, queue, weight, maxInFlight, time.Now().UTC())
+	return err
+}
+
+func (r *SQLiteRepository) SetQueueRetryPolicy(ctx context.Context, queue string, policy *models.RetryPolicy) error {
+	query := `
+		INSERT INTO queues (name, retry_policy, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE
+		SET retry_policy = excluded.retry_policy, updated_at = excluded.updated_at
+	`
+
+	retryPolicy, err := encodeRetryPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, queue, retryPolicy, time.Now().UTC())
+	return err
+}
+
+func (r *SQLiteRepository) GetQueue(ctx context.Context, name string) (*models.Queue, error) {
+	query := `SELECT ` + queueColumns + ` FROM queues WHERE name = ?`
+
+	queue, err := scanQueue(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return queue, err
+}
+
+func (r *SQLiteRepository) ListQueues(ctx context.Context) ([]*models.Queue, error) {
+	query := `
+		SELECT ` + queueColumns + `
+		FROM queues
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queues []*models.Queue
+	for rows.Next() {
+		queue, err := scanQueue(rows)
+		if err != nil {
+			return nil, err
+		}
+		queues = append(queues, queue)
+	}
+
+	return queues, rows.Err()
+}
+
+// sqliteDeadJobsWhere selects the dead jobs matched by a DeadJobFilter
+// passed as ?1 (queue) and ?2 (ids as a JSON array).
+const sqliteDeadJobsWhere = `
+		WHERE status = 'dead'
+		AND (?1 = '' OR queue = ?1)
+		AND (json_array_length(?2) = 0 OR id IN (SELECT value FROM json_each(?2)))
+	`
+
+// RequeueDeadJobs returns matching dead jobs to pending to run now, with
+// their attempts, snoozes and last error reset.
+func (r *SQLiteRepository) RequeueDeadJobs(ctx context.Context, filter DeadJobFilter) (int, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', run_at = ?3, attempts = 0, snoozes = 0, last_error = NULL, updated_at = ?3
+	` + sqliteDeadJobsWhere
+
+	ids, err := deadJobIDs(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, filter.Queue, ids, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	requeued, err := result.RowsAffected()
+	return int(requeued), err
+}
+
+// PurgeDeadJobs deletes matching dead jobs along with their attempts.
+func (r *SQLiteRepository) PurgeDeadJobs(ctx context.Context, filter DeadJobFilter) (int, error) {
+	query := `DELETE FROM jobs` + sqliteDeadJobsWhere
+
+	ids, err := deadJobIDs(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, filter.Queue, ids)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
+
+func deadJobIDs(filter DeadJobFilter) (string, error) {
+	if filter.IDs == nil {
+		return "[]", nil
+	}
+	ids, err := json.Marshal(filter.IDs)
+	return string(ids), err
+}
+
+func (r *SQLiteRepository) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	query := `
+		INSERT INTO schedules (
+			id, name, cron, timezone, queue, type, payload, priority, max_retries,
+			enabled, next_run_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now().UTC()
+
+	_, err := r.db.ExecContext(ctx,
+		query,
+		schedule.ID, schedule.Name, schedule.Cron, schedule.Timezone, schedule.Queue,
+		schedule.Type, []byte(schedule.Payload), schedule.Priority, schedule.MaxRetries,
+		schedule.Enabled, schedule.NextRunAt.UTC(), now, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLiteRepository) GetSchedule(ctx context.Context, id string) (*models.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = ?`
+
+	schedule, err := scanSchedule(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (r *SQLiteRepository) ListSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules ORDER BY created_at ASC`
+
+	return r.querySchedules(ctx, query)
+}
+
+func (r *SQLiteRepository) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	query := `
+		UPDATE schedules
+		SET name = ?, cron = ?, timezone = ?, queue = ?, type = ?, payload = ?,
+		    priority = ?, max_retries = ?, enabled = ?, next_run_at = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	now := time.Now().UTC()
+
+	_, err := r.db.ExecContext(ctx,
+		query,
+		schedule.Name, schedule.Cron, schedule.Timezone, schedule.Queue, schedule.Type,
+		[]byte(schedule.Payload), schedule.Priority, schedule.MaxRetries, schedule.Enabled,
+		schedule.NextRunAt.UTC(), now, schedule.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	schedule.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLiteRepository) DeleteSchedule(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = ?`, id)
+	return err
+}
+
+func (r *SQLiteRepository) DueSchedules(ctx context.Context, now time.Time) ([]*models.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE enabled AND next_run_at <= ?
+		ORDER BY next_run_at ASC
+	`
+
+	return r.querySchedules(ctx, query, now.UTC())
+}
+
+// FireSchedule advances the schedule with a compare-and-swap on next_run_at
+// and inserts the job in the same transaction, so a tick produces exactly
+// one job however many processes share the database file.
+func (r *SQLiteRepository) FireSchedule(ctx context.Context, scheduleID string, runAt, nextRunAt time.Time, job *models.Job) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE schedules
+		SET next_run_at = ?1, last_run_at = ?2, updated_at = ?3
+		WHERE id = ?4 AND enabled AND next_run_at = ?2
+	`
+
+	result, err := tx.ExecContext(ctx, query, nextRunAt.UTC(), runAt.UTC(), time.Now().UTC(), scheduleID)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil || claimed == 0 {
+		return false, err
+	}
+
+	if _, err := r.insertJob(ctx, tx, job); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *SQLiteRepository) querySchedules(ctx context.Context, query string, args ...any) ([]*models.Schedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*models.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+// Store is the persistence layer shared by workers, the dispatcher and the
+// HTTP API.
+type Store interface {
+	// CreateJob returns nil once job is inserted, or the job holding its
+	// idempotency key. Holders created before releaseBefore give the key up.
+	CreateJob(ctx context.Context, job *models.Job, releaseBefore time.Time) (*models.Job, error)
+	// CreateJobs runs CreateJob for each job in one transaction; a key
+	// holder may be an earlier job in the batch.
+	CreateJobs(ctx context.Context, jobs []*models.Job, releaseBefore time.Time) ([]*models.Job, error)
+	GetJobByID(ctx context.Context, id string) (*models.Job, error)
+	// PickJobs leases up to limit jobs from queues, or from any queue if
+	// empty, with a new lease token each, and counts the run in attempts.
+	PickJobs(ctx context.Context, lockedBy string, queues []string, limit int, timeout time.Duration) ([]*models.Job, error)
+	// ExtendLease, like every method taking a lease token, returns
+	// ErrLeaseLost once the job has left processing under that token.
+	ExtendLease(ctx context.Context, jobID string, leaseToken int64, timeout time.Duration) error
+	// FinishJob sets a leased job's final status and last error.
+	FinishJob(ctx context.Context, jobID string, leaseToken int64, status models.JobStatus, lastError string) error
+	// UpdateJobStatus is not fenced; it is meant for admin actions such as
+	// cancelling.
+	UpdateJobStatus(ctx context.Context, jobID string, status models.JobStatus) error
+	// UpdateJobForRetry puts a leased job back to pending until runAt.
+	UpdateJobForRetry(ctx context.Context, jobID string, leaseToken int64, runAt time.Time, lastError string) error
+	// SnoozeJob is UpdateJobForRetry counting a snooze instead of a retry.
+	SnoozeJob(ctx context.Context, jobID string, leaseToken int64, runAt time.Time) error
+	// CreateJobAttempt numbers attempt after the job's existing ones, so
+	// numbering carries on across a requeue.
+	CreateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error
+	// UpdateJobAttempt records an outcome unless the attempt was abandoned.
+	UpdateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error
+	ExpiredLeases(ctx context.Context, limit int) ([]*models.Job, error)
+	// AbandonJob moves a job listed by ExpiredLeases to status, recording
+	// attempt. It reports false if the lease changed since it was listed.
+	AbandonJob(ctx context.Context, job *models.Job, status models.JobStatus, attempt *models.JobAttempt) (bool, error)
+	ListJobs(ctx context.Context, filter JobFilter) ([]*models.Job, error)
+	GetJobAttempts(ctx context.Context, jobID string) ([]*models.JobAttempt, error)
+	GetJobStats(ctx context.Context) (map[string]int, error)
+	SetQueuePaused(ctx context.Context, queue string, paused bool) error
+	ConfigureQueue(ctx context.Context, queue string, weight int, maxInFlight int) error
+	// SetQueueRetryPolicy clears the queue's policy when policy is nil.
+	SetQueueRetryPolicy(ctx context.Context, queue string, policy *models.RetryPolicy) error
+	// GetQueue returns nil for a queue that was never configured.
+	GetQueue(ctx context.Context, name string) (*models.Queue, error)
+	ListQueues(ctx context.Context) ([]*models.Queue, error)
+
+	// RequeueDeadJobs makes dead jobs pending with attempts, snoozes and
+	// last error reset.
+	RequeueDeadJobs(ctx context.Context, filter DeadJobFilter) (int, error)
+	// PurgeDeadJobs deletes dead jobs along with their attempts.
+	PurgeDeadJobs(ctx context.Context, filter DeadJobFilter) (int, error)
+
+	CreateSchedule(ctx context.Context, schedule *models.Schedule) error
+	GetSchedule(ctx context.Context, id string) (*models.Schedule, error)
+	ListSchedules(ctx context.Context) ([]*models.Schedule, error)
+	UpdateSchedule(ctx context.Context, schedule *models.Schedule) error
+	DeleteSchedule(ctx context.Context, id string) error
+	DueSchedules(ctx context.Context, now time.Time) ([]*models.Schedule, error)
+	// FireSchedule advances next_run_at from runAt and creates job atomically,
+	// reporting false if runAt no longer matches because another node won.
+	FireSchedule(ctx context.Context, scheduleID string, runAt, nextRunAt time.Time, job *models.Job) (bool, error)
+}
+
+// ErrLeaseLost is returned when a lease token no longer matches the job.
+var ErrLeaseLost = errors.New("job lease lost")
+
+var (
+	_ Store = (*PostgresRepository)(nil)
+	_ Store = (*SQLiteRepository)(nil)
+	_ Store = (*MemoryRepository)(nil)
+)
+
+// JobFilter narrows ListJobs results. Empty fields match everything.
+type JobFilter struct {
+	Status string
+	Queue  string
+	Type   string
+	Limit  int
+	// Scheduled keeps only jobs whose run_at is in the future and lists
+	// them soonest first.
+	Scheduled bool
+}
+
+// DeadJobFilter selects dead jobs for bulk requeue and purge. Empty fields
+// match every dead job.
+type DeadJobFilter struct {
+	IDs   []string
+	Queue string
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx, so a statement can run
+// on its own or as part of a larger transaction.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// fenced turns a lease-token guarded update that matched no rows into
+// ErrLeaseLost.
+func fenced(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
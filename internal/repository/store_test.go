@@ -0,0 +1,398 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+// testStores runs test against every Store that needs no external server.
+func testStores(t *testing.T, test func(t *testing.T, store Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryRepository())
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := OpenSQLite(filepath.Join(t.TempDir(), "gosynq.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		repo := NewSQLiteRepository(db)
+		if err := repo.Migrate(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		test(t, repo)
+	})
+}
+
+func newJob(queue string) *models.Job {
+	return &models.Job{
+		ID:         uuid.New().String(),
+		Queue:      queue,
+		Type:       "test",
+		Payload:    json.RawMessage(`{}`),
+		MaxRetries: 3,
+		RunAt:      time.Now().Add(-time.Second),
+		Status:     models.StatusPending,
+		Priority:   models.PriorityNormal,
+	}
+}
+
+func createJobs(t *testing.T, store Store, jobs ...*models.Job) {
+	t.Helper()
+
+	for _, job := range jobs {
+		existing, err := store.CreateJob(context.Background(), job, time.Time{})
+		if err != nil {
+			t.Fatalf("CreateJob() = %v", err)
+		}
+		if existing != nil {
+			t.Fatalf("CreateJob() found job %s holding key %q", existing.ID, job.IdempotencyKey)
+		}
+	}
+}
+
+func pickOne(t *testing.T, store Store, queues ...string) *models.Job {
+	t.Helper()
+
+	jobs, err := store.PickJobs(context.Background(), "worker-1", queues, 1, time.Minute)
+	if err != nil {
+		t.Fatalf("PickJobs() = %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("PickJobs() picked %d jobs, want 1", len(jobs))
+	}
+	return jobs[0]
+}
+
+func getJob(t *testing.T, store Store, id string) *models.Job {
+	t.Helper()
+
+	job, err := store.GetJobByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetJobByID() = %v", err)
+	}
+	return job
+}
+
+func TestStoreIdempotencyKeys(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		first := newJob("email")
+		first.IdempotencyKey = "welcome-42"
+		createJobs(t, store, first)
+
+		duplicate := newJob("email")
+		duplicate.IdempotencyKey = "welcome-42"
+		existing, err := store.CreateJob(ctx, duplicate, time.Time{})
+		if err != nil {
+			t.Fatalf("CreateJob() = %v", err)
+		}
+		if existing == nil || existing.ID != first.ID {
+			t.Fatalf("CreateJob() = %v, want the first job", existing)
+		}
+		if getJob(t, store, duplicate.ID) != nil {
+			t.Error("duplicate job was stored")
+		}
+
+		// Keys are per queue
+		other := newJob("reports")
+		other.IdempotencyKey = "welcome-42"
+		createJobs(t, store, other)
+
+		// A batch may collide with itself
+		a, b := newJob("email"), newJob("email")
+		a.IdempotencyKey, b.IdempotencyKey = "batch-1", "batch-1"
+		results, err := store.CreateJobs(ctx, []*models.Job{a, b}, time.Time{})
+		if err != nil {
+			t.Fatalf("CreateJobs() = %v", err)
+		}
+		if results[0] != nil || results[1] == nil || results[1].ID != a.ID {
+			t.Errorf("CreateJobs() = %v, want b to collide with a", results)
+		}
+
+		// Keys held past the retention cutoff are given up to the new job
+		renewed := newJob("email")
+		renewed.IdempotencyKey = "welcome-42"
+		existing, err = store.CreateJob(ctx, renewed, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("CreateJob() = %v", err)
+		}
+		if existing != nil {
+			t.Fatalf("CreateJob() = %v, want the key released", existing.ID)
+		}
+		if key := getJob(t, store, first.ID).IdempotencyKey; key != "" {
+			t.Errorf("first job still holds key %q", key)
+		}
+	})
+}
+
+func TestStoreLeaseFencing(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		job := newJob("email")
+		createJobs(t, store, job)
+
+		picked := pickOne(t, store)
+		if picked.Status != models.StatusProcessing || picked.Attempts != 1 {
+			t.Fatalf("picked job is %s with %d attempts, want processing with 1", picked.Status, picked.Attempts)
+		}
+
+		stale := picked.LeaseToken + 1
+		fencedCalls := map[string]error{
+			"ExtendLease":       store.ExtendLease(ctx, job.ID, stale, time.Minute),
+			"FinishJob":         store.FinishJob(ctx, job.ID, stale, models.StatusCompleted, ""),
+			"UpdateJobForRetry": store.UpdateJobForRetry(ctx, job.ID, stale, time.Now(), "boom"),
+			"SnoozeJob":         store.SnoozeJob(ctx, job.ID, stale, time.Now()),
+		}
+		for name, err := range fencedCalls {
+			if !errors.Is(err, ErrLeaseLost) {
+				t.Errorf("%s with a stale token = %v, want ErrLeaseLost", name, err)
+			}
+		}
+		if status := getJob(t, store, job.ID).Status; status != models.StatusProcessing {
+			t.Fatalf("job is %s after fenced calls, want processing", status)
+		}
+
+		if err := store.ExtendLease(ctx, job.ID, picked.LeaseToken, time.Minute); err != nil {
+			t.Errorf("ExtendLease() = %v", err)
+		}
+		if err := store.FinishJob(ctx, job.ID, picked.LeaseToken, models.StatusCompleted, ""); err != nil {
+			t.Fatalf("FinishJob() = %v", err)
+		}
+		if err := store.FinishJob(ctx, job.ID, picked.LeaseToken, models.StatusCompleted, ""); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("second FinishJob() = %v, want ErrLeaseLost", err)
+		}
+	})
+}
+
+func TestStorePickJobsQueues(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		if err := store.ConfigureQueue(ctx, "limited", 1, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetQueuePaused(ctx, "paused", true); err != nil {
+			t.Fatal(err)
+		}
+		future := newJob("email")
+		future.RunAt = time.Now().Add(time.Hour)
+		createJobs(t, store, newJob("limited"), newJob("limited"), newJob("paused"), newJob("email"), future)
+
+		jobs, err := store.PickJobs(ctx, "worker-1", nil, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("PickJobs() = %v", err)
+		}
+		picked := make(map[string]int)
+		for _, job := range jobs {
+			picked[job.Queue]++
+		}
+		if picked["limited"] != 1 || picked["email"] != 1 || picked["paused"] != 0 || len(jobs) != 2 {
+			t.Errorf("PickJobs() picked %v, want one limited and one due email job", picked)
+		}
+
+		jobs, err = store.PickJobs(ctx, "worker-1", []string{"paused", "limited"}, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("PickJobs() = %v", err)
+		}
+		if len(jobs) != 0 {
+			t.Errorf("PickJobs() picked %d jobs from a paused and a full queue", len(jobs))
+		}
+	})
+}
+
+func TestStoreAttemptNumbering(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		job := newJob("email")
+		createJobs(t, store, job)
+		picked := pickOne(t, store)
+
+		first := &models.JobAttempt{ID: uuid.New().String(), JobID: job.ID, StartedAt: time.Now(), Status: models.StatusProcessing}
+		if err := store.CreateJobAttempt(ctx, first); err != nil {
+			t.Fatalf("CreateJobAttempt() = %v", err)
+		}
+		if first.AttemptNumber != 1 {
+			t.Errorf("first attempt is number %d, want 1", first.AttemptNumber)
+		}
+
+		// The first run fails and the job is retried
+		if err := store.UpdateJobForRetry(ctx, job.ID, picked.LeaseToken, time.Now().Add(-time.Second), "boom"); err != nil {
+			t.Fatal(err)
+		}
+		first.Status = models.StatusFailed
+		if err := store.UpdateJobAttempt(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+
+		pickOne(t, store)
+		second := &models.JobAttempt{ID: uuid.New().String(), JobID: job.ID, StartedAt: time.Now(), Status: models.StatusProcessing}
+		if err := store.CreateJobAttempt(ctx, second); err != nil {
+			t.Fatalf("CreateJobAttempt() = %v", err)
+		}
+		if second.AttemptNumber != 2 {
+			t.Errorf("second attempt is number %d, want 2", second.AttemptNumber)
+		}
+
+		attempts, err := store.GetJobAttempts(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempts) != 2 || attempts[0].Status != models.StatusFailed || attempts[1].Status != models.StatusProcessing {
+			t.Errorf("GetJobAttempts() = %+v, want a failed then a running attempt", attempts)
+		}
+	})
+}
+
+func TestStoreDeadJobs(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		email, report, other := newJob("email"), newJob("reports"), newJob("reports")
+		createJobs(t, store, email, report, other)
+		for range 3 {
+			picked := pickOne(t, store)
+			attempt := &models.JobAttempt{ID: uuid.New().String(), JobID: picked.ID, StartedAt: time.Now(), Status: models.StatusFailed}
+			if err := store.CreateJobAttempt(ctx, attempt); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.FinishJob(ctx, picked.ID, picked.LeaseToken, models.StatusDead, "boom"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		requeued, err := store.RequeueDeadJobs(ctx, DeadJobFilter{Queue: "email"})
+		if err != nil || requeued != 1 {
+			t.Fatalf("RequeueDeadJobs() = %d, %v, want 1", requeued, err)
+		}
+		job := getJob(t, store, email.ID)
+		if job.Status != models.StatusPending || job.Attempts != 0 || job.Snoozes != 0 || job.LastError != "" {
+			t.Errorf("requeued job is %s with %d attempts, %d snoozes and error %q, want a clean pending job",
+				job.Status, job.Attempts, job.Snoozes, job.LastError)
+		}
+
+		// Attempt numbers carry on after the requeue
+		pickOne(t, store, "email")
+		attempt := &models.JobAttempt{ID: uuid.New().String(), JobID: email.ID, StartedAt: time.Now(), Status: models.StatusProcessing}
+		if err := store.CreateJobAttempt(ctx, attempt); err != nil {
+			t.Fatal(err)
+		}
+		if attempt.AttemptNumber != 2 {
+			t.Errorf("attempt after requeue is number %d, want 2", attempt.AttemptNumber)
+		}
+
+		purged, err := store.PurgeDeadJobs(ctx, DeadJobFilter{IDs: []string{report.ID}})
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeDeadJobs() = %d, %v, want 1", purged, err)
+		}
+		if getJob(t, store, report.ID) != nil {
+			t.Error("purged job still exists")
+		}
+		attempts, err := store.GetJobAttempts(ctx, report.ID)
+		if err != nil || len(attempts) != 0 {
+			t.Errorf("purged job has %d attempts, %v", len(attempts), err)
+		}
+
+		// An empty filter matches every dead job, and only dead jobs
+		purged, err = store.PurgeDeadJobs(ctx, DeadJobFilter{})
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeDeadJobs() = %d, %v, want 1", purged, err)
+		}
+		if getJob(t, store, email.ID) == nil {
+			t.Error("running job was purged")
+		}
+	})
+}
+
+func TestStoreFireSchedule(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		schedule := &models.Schedule{
+			ID:        uuid.New().String(),
+			Name:      "nightly",
+			Cron:      "@daily",
+			Timezone:  "UTC",
+			Queue:     "reports",
+			Payload:   json.RawMessage(`{}`),
+			Priority:  models.PriorityNormal,
+			Enabled:   true,
+			NextRunAt: time.Now().Add(-time.Minute).Truncate(time.Second),
+		}
+		if err := store.CreateSchedule(ctx, schedule); err != nil {
+			t.Fatalf("CreateSchedule() = %v", err)
+		}
+		stored, err := store.GetSchedule(ctx, schedule.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		runAt := stored.NextRunAt
+		nextRunAt := runAt.Add(24 * time.Hour)
+		job := newJob("reports")
+		fired, err := store.FireSchedule(ctx, schedule.ID, runAt, nextRunAt, job)
+		if err != nil || !fired {
+			t.Fatalf("FireSchedule() = %v, %v, want true", fired, err)
+		}
+		if getJob(t, store, job.ID) == nil {
+			t.Error("FireSchedule() did not create the job")
+		}
+
+		// Another node firing the same tick loses
+		late := newJob("reports")
+		fired, err = store.FireSchedule(ctx, schedule.ID, runAt, nextRunAt, late)
+		if err != nil || fired {
+			t.Fatalf("second FireSchedule() = %v, %v, want false", fired, err)
+		}
+		if getJob(t, store, late.ID) != nil {
+			t.Error("losing FireSchedule() created a job")
+		}
+
+		stored, err = store.GetSchedule(ctx, schedule.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !stored.NextRunAt.Equal(nextRunAt) || stored.LastRunAt == nil || !stored.LastRunAt.Equal(runAt) {
+			t.Errorf("schedule runs at %v after %v, want %v after %v", stored.NextRunAt, stored.LastRunAt, nextRunAt, runAt)
+		}
+
+		// Disabled schedules do not fire
+		stored.Enabled = false
+		if err := store.UpdateSchedule(ctx, stored); err != nil {
+			t.Fatal(err)
+		}
+		fired, err = store.FireSchedule(ctx, schedule.ID, stored.NextRunAt, stored.NextRunAt.Add(time.Hour), newJob("reports"))
+		if err != nil || fired {
+			t.Errorf("FireSchedule() on a disabled schedule = %v, %v, want false", fired, err)
+		}
+	})
+}
+
+func TestStoreListJobsLimit(t *testing.T) {
+	testStores(t, func(t *testing.T, store Store) {
+		createJobs(t, store, newJob("email"), newJob("email"), newJob("email"))
+
+		for limit, want := range map[int]int{0: 3, -1: 3, 2: 2, 5: 3} {
+			jobs, err := store.ListJobs(context.Background(), JobFilter{Limit: limit})
+			if err != nil {
+				t.Fatalf("ListJobs() = %v", err)
+			}
+			if len(jobs) != want {
+				t.Errorf("ListJobs() with limit %d = %d jobs, want %d", limit, len(jobs), want)
+			}
+		}
+	})
+}
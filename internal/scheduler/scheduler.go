@@ -0,0 +1,220 @@
+// Package scheduler enqueues jobs from persisted cron schedules.
+//
+// Every node runs a Scheduler, but a tick only ever produces one job: nodes
+// race to advance the schedule's next_run_at with a compare-and-swap and
+// only the winner enqueues. Ticks missed while no node was running are not
+// replayed; the schedule fires once and carries on from the current time.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/repository"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrInvalidSchedule is returned for schedules with a malformed cron
+// expression or an unknown timezone.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+type Scheduler struct {
+	repo       repository.Store
+	eventChan  chan<- models.JobEvent
+	config     Config
+	shutdownCh chan struct{}
+	shutdownWg sync.WaitGroup
+}
+
+type Config struct {
+	// PollInterval is how often due schedules are checked. It bounds how
+	// late a tick can fire.
+	PollInterval time.Duration
+}
+
+func New(repo repository.Store, eventChan chan<- models.JobEvent, config Config) *Scheduler {
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+
+	return &Scheduler{
+		repo:       repo,
+		eventChan:  eventChan,
+		config:     config,
+		shutdownCh: make(chan struct{}),
+	}
+}
+
+// NextRun returns the first time after after at which expr fires in the
+// named timezone. An empty timezone means UTC.
+func NextRun(expr string, timezone string, after time.Time) (time.Time, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, timezone)
+	}
+
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	next := schedule.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: %q never fires", ErrInvalidSchedule, expr)
+	}
+
+	return next, nil
+}
+
+// CreateSchedule validates schedule, fills in defaults and stores it. The
+// first run is the next tick after now.
+func (s *Scheduler) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	if err := s.prepare(schedule); err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateSchedule(ctx, schedule); err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSchedule validates and stores schedule, recomputing its next run
+// from now. A tick already due but not yet fired is skipped.
+func (s *Scheduler) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	if err := s.prepare(schedule); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateSchedule(ctx, schedule); err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) DeleteSchedule(ctx context.Context, id string) error {
+	if err := s.repo.DeleteSchedule(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) prepare(schedule *models.Schedule) error {
+	if schedule.Cron == "" {
+		return fmt.Errorf("%w: cron is required", ErrInvalidSchedule)
+	}
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if schedule.Queue == "" {
+		schedule.Queue = "default"
+	}
+	if schedule.Priority == "" {
+		schedule.Priority = models.PriorityNormal
+	}
+	if len(schedule.Payload) == 0 {
+		schedule.Payload = []byte("{}")
+	}
+
+	next, err := NextRun(schedule.Cron, schedule.Timezone, time.Now())
+	if err != nil {
+		return err
+	}
+	schedule.NextRunAt = next
+
+	return nil
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	log.Println("Starting scheduler")
+
+	s.shutdownWg.Add(1)
+	go func() {
+		defer s.shutdownWg.Done()
+
+		ticker := time.NewTicker(s.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.shutdownCh:
+				return
+			case <-ticker.C:
+				s.fireDue(ctx)
+			}
+		}
+	}()
+}
+
+// fireDue enqueues a job for every schedule whose next run has passed.
+func (s *Scheduler) fireDue(ctx context.Context) {
+	now := time.Now()
+
+	due, err := s.repo.DueSchedules(ctx, now)
+	if err != nil {
+		log.Printf("Scheduler: failed to load due schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range due {
+		next, err := NextRun(schedule.Cron, schedule.Timezone, now)
+		if err != nil {
+			log.Printf("Scheduler: schedule %s: %v", schedule.ID, err)
+			continue
+		}
+
+		job := &models.Job{
+			ID:         uuid.New().String(),
+			Queue:      schedule.Queue,
+			Type:       schedule.Type,
+			Payload:    schedule.Payload,
+			MaxRetries: schedule.MaxRetries,
+			Priority:   schedule.Priority,
+			RunAt:      now,
+			Status:     models.StatusPending,
+		}
+
+		fired, err := s.repo.FireSchedule(ctx, schedule.ID, schedule.NextRunAt, next, job)
+		if err != nil {
+			log.Printf("Scheduler: failed to fire schedule %s: %v", schedule.ID, err)
+			continue
+		}
+		if !fired {
+			// Another node fired this tick
+			continue
+		}
+
+		log.Printf("Scheduler: schedule %s enqueued job %s", schedule.ID, job.ID)
+
+		s.eventChan <- models.JobEvent{
+			Type:      "created",
+			JobID:     job.ID,
+			Queue:     job.Queue,
+			Timestamp: now,
+			Payload:   job.Payload,
+		}
+	}
+}
+
+func (s *Scheduler) Shutdown() {
+	log.Println("Shutting down scheduler")
+
+	close(s.shutdownCh)
+	s.shutdownWg.Wait()
+}
@@ -54,7 +54,10 @@ func (s *WebSocketServer) processEvents(ctx context.Context) {
 		select {
 		case <-s.shutdownCh:
 			return
-		case event := <-s.eventChan:
+		case event, ok := <-s.eventChan:
+			if !ok {
+				return
+			}
 			s.broadcastEvent(event)
 		}
 	}
@@ -168,14 +171,15 @@ func (s *WebSocketServer) removeClient(client *Client) {
 func (s *WebSocketServer) Shutdown() {
 	close(s.shutdownCh)
 
-	// Close all client connections
+	// Close all client connections. Clients are removed under the lock but
+	// the pumps are waited on without it, since they call removeClient.
 	s.clientsMutex.Lock()
-	defer s.clientsMutex.Unlock()
-
 	for client := range s.clients {
 		close(client.send)
 		client.conn.Close()
+		delete(s.clients, client)
 	}
+	s.clientsMutex.Unlock()
 
 	s.shutdownWg.Wait()
 }
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+// SkipRetry fails the job for good when a handler returns it, or an error
+// wrapping it, whatever retries the job has left.
+var SkipRetry = errors.New("skip retry")
+
+type snoozeError struct {
+	delay time.Duration
+}
+
+func (e *snoozeError) Error() string {
+	return fmt.Sprintf("snoozed for %v", e.delay)
+}
+
+// Snooze returns an error that puts the job back to pending for d. The
+// attempt is recorded as snoozed and does not use up a retry.
+func Snooze(d time.Duration) error {
+	return &snoozeError{delay: max(d, 0)}
+}
+
+type retryAfterError struct {
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return fmt.Sprintf("retry after %v", e.delay)
+}
+
+// RetryAfter returns an error that fails the attempt like any other but
+// retries the job after d instead of its retry policy's delay.
+func RetryAfter(d time.Duration) error {
+	return &retryAfterError{delay: max(d, 0)}
+}
+
+// failureStatus is the status recorded for an attempt that failed with err.
+func failureStatus(err error) models.JobStatus {
+	var retryAfter *retryAfterError
+	switch {
+	case errors.Is(err, SkipRetry):
+		return models.StatusSkipped
+	case errors.As(err, &retryAfter):
+		return models.StatusRetryAfter
+	}
+	return models.StatusFailed
+}
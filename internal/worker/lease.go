@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/repository"
+)
+
+// ErrLeaseLost is returned by ExtendLease when the job is no longer held by
+// this worker, typically because the lease ran out and the job was
+// reclaimed. The handler should stop; its result will not be kept.
+var ErrLeaseLost = repository.ErrLeaseLost
+
+type leaseKey struct{}
+
+// lease is a worker's claim on the job it is processing. The job's context
+// is cancelled when the lease runs out or is found to be lost. token is the
+// lease token the job was picked with.
+type lease struct {
+	repo    repository.Store
+	jobID   string
+	token   int64
+	timeout time.Duration
+	cancel  context.CancelFunc
+
+	mu    sync.Mutex
+	timer *time.Timer
+	lost  bool
+}
+
+func newLease(repo repository.Store, job *models.Job, timeout time.Duration, cancel context.CancelFunc) *lease {
+	return &lease{
+		repo:    repo,
+		jobID:   job.ID,
+		token:   job.LeaseToken,
+		timeout: timeout,
+		cancel:  cancel,
+		timer:   time.AfterFunc(timeout, cancel),
+	}
+}
+
+func (l *lease) extend(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.lost {
+		return ErrLeaseLost
+	}
+
+	err := l.repo.ExtendLease(ctx, l.jobID, l.token, l.timeout)
+	if errors.Is(err, ErrLeaseLost) {
+		l.lost = true
+		l.cancel()
+		return ErrLeaseLost
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extend lease: %w", err)
+	}
+
+	l.timer.Reset(l.timeout)
+	return nil
+}
+
+// heartbeat extends the lease every interval until ctx is done.
+func (l *lease) heartbeat(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := l.extend(ctx)
+			if errors.Is(err, ErrLeaseLost) {
+				log.Printf("Job %s: lease lost, cancelling handler", l.jobID)
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Job %s: %v", l.jobID, err)
+			}
+		}
+	}
+}
+
+func (l *lease) stop() {
+	l.timer.Stop()
+}
+
+// ExtendLease renews the lease on the job being processed under ctx for
+// another visibility timeout. Handlers that run longer than the timeout and
+// have automatic heartbeats disabled call it as they make progress.
+func ExtendLease(ctx context.Context) error {
+	l, ok := ctx.Value(leaseKey{}).(*lease)
+	if !ok {
+		return errors.New("no job lease in context")
+	}
+	return l.extend(ctx)
+}
@@ -2,97 +2,99 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"math"
 	"math/rand"
 	"time"
 
 	"github.com/arthures11/gosynq/internal/models"
 	"github.com/arthures11/gosynq/internal/repository"
+	"github.com/google/uuid"
 )
 
 type Worker struct {
 	id         string
-	repo       *repository.PostgresRepository
+	repo       repository.Store
 	jobHandler JobHandler
 	config     WorkerConfig
 	eventChan  chan<- models.JobEvent
-	shutdownCh chan struct{}
 }
 
 type WorkerConfig struct {
 	VisibilityTimeout time.Duration
 	RetryStrategy     RetryStrategy
+	// HeartbeatInterval is how often the lease on a running job is
+	// extended automatically. Zero leaves it to the handler to call
+	// ExtendLease before the visibility timeout runs out.
+	HeartbeatInterval time.Duration
 }
 
+// RetryStrategy is the server-wide retry policy. Queues and jobs may
+// override any part of it except MaxAttempts.
 type RetryStrategy struct {
-	Type        string
-	Interval    int
-	MaxAttempts int
+	Type            string
+	Interval        int
+	MaxAttempts     int
+	ExponentialBase float64
+	MaxDelay        time.Duration
+	Jitter          float64
+}
+
+func (s RetryStrategy) policy() models.RetryPolicy {
+	return models.RetryPolicy{
+		Strategy: s.Type,
+		Interval: models.Duration(time.Duration(s.Interval) * time.Second),
+		Base:     s.ExponentialBase,
+		MaxDelay: models.Duration(s.MaxDelay),
+		Jitter:   s.Jitter,
+	}
+}
+
+// allows reports whether a job that has run attempts times stays under the
+// MaxAttempts cap.
+func (s RetryStrategy) allows(attempts int) bool {
+	return s.MaxAttempts <= 0 || attempts < s.MaxAttempts
 }
 
 type JobHandler func(ctx context.Context, job *models.Job) error
 
-func NewWorker(id string, repo *repository.PostgresRepository, handler JobHandler, config WorkerConfig, eventChan chan<- models.JobEvent) *Worker {
+// ErrNoHandler is returned by a JobHandler when nothing is registered to
+// process the job. Jobs failing with it are never retried.
+var ErrNoHandler = errors.New("no handler registered")
+
+func NewWorker(id string, repo repository.Store, handler JobHandler, config WorkerConfig, eventChan chan<- models.JobEvent) *Worker {
 	return &Worker{
 		id:         id,
 		repo:       repo,
 		jobHandler: handler,
 		config:     config,
 		eventChan:  eventChan,
-		shutdownCh: make(chan struct{}),
 	}
 }
 
-func (w *Worker) Start(ctx context.Context) {
+// Start processes the jobs handed to it on jobs until the channel is
+// closed. release is called after each job, whatever its outcome, to give
+// the worker's slot back to the dispatcher.
+func (w *Worker) Start(ctx context.Context, jobs <-chan *models.Job, release func()) {
 	log.Printf("Worker %s starting", w.id)
 
-	for {
-		select {
-		case <-w.shutdownCh:
-			log.Printf("Worker %s shutting down", w.id)
-			return
-		default:
-			log.Printf("Worker %s attempting to pick and process job", w.id)
-			job, err := w.pickAndProcessJob(ctx)
-			if err != nil {
-				log.Printf("Worker %s error processing job: %v", w.id, err)
-				// Add some jitter to avoid thundering herd
-				time.Sleep(time.Duration(rand.Intn(1000)) * time.Millisecond)
-				continue
-			}
-
-			if job == nil {
-				log.Printf("Worker %s: no jobs available, waiting", w.id)
-				// No jobs available, wait a bit
-				time.Sleep(1 * time.Second)
-				continue
-			}
-
+	for job := range jobs {
+		if err := w.runJob(ctx, job); err != nil {
+			log.Printf("Worker %s error processing job: %v", w.id, err)
+		} else {
 			log.Printf("Worker %s successfully processed job %s", w.id, job.ID)
-			// Job was successfully processed, add small delay before next job
-			// This allows the job lifecycle to complete properly
-			time.Sleep(500 * time.Millisecond)
 		}
+		release()
 	}
-}
 
-func (w *Worker) pickAndProcessJob(ctx context.Context) (*models.Job, error) {
-	log.Printf("Worker %s: attempting to pick job from queue", w.id)
-	// Atomic job pickup
-	job, err := w.repo.PickJob(ctx, w.id, w.config.VisibilityTimeout)
-	if err != nil {
-		log.Printf("Worker %s: failed to pick job: %v", w.id, err)
-		return nil, fmt.Errorf("failed to pick job: %w", err)
-	}
-
-	if job == nil {
-		log.Printf("Worker %s: no jobs available in queue", w.id)
-		return nil, nil // No jobs available
-	}
+	log.Printf("Worker %s shutting down", w.id)
+}
 
-	log.Printf("Worker %s: picked job %s from queue %s, status: %s", w.id, job.ID, job.Queue, job.Status)
+// runJob processes a job the dispatcher has already claimed for this
+// worker.
+func (w *Worker) runJob(ctx context.Context, job *models.Job) error {
+	log.Printf("Worker %s: received job %s from queue %s", w.id, job.ID, job.Queue)
 
 	// Send job started event
 	w.eventChan <- models.JobEvent{
@@ -103,31 +105,34 @@ func (w *Worker) pickAndProcessJob(ctx context.Context) (*models.Job, error) {
 		Payload:   job.Payload,
 	}
 
-	log.Printf("Worker %s: sent started event for job %s", w.id, job.ID)
-
-	// Process the job
-	log.Printf("Worker %s: starting job processing for job %s", w.id, job.ID)
-	err = w.processJob(ctx, job)
-	if err != nil {
-		log.Printf("Worker %s: job processing failed for job %s: %v", w.id, job.ID, err)
-		return job, fmt.Errorf("job processing failed: %w", err)
+	if err := w.processJob(ctx, job); err != nil {
+		return fmt.Errorf("job processing failed: %w", err)
 	}
 
-	log.Printf("Worker %s: successfully completed job %s", w.id, job.ID)
-	return job, nil
+	return nil
 }
 
 func (w *Worker) processJob(ctx context.Context, job *models.Job) error {
-	ctx, cancel := context.WithTimeout(ctx, w.config.VisibilityTimeout)
+	// The handler's context lives as long as the lease; bookkeeping below
+	// uses ctx so the outcome is still recorded after a timeout.
+	jobCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// Create job attempt record
+	lease := newLease(w.repo, job, w.config.VisibilityTimeout, cancel)
+	defer lease.stop()
+	jobCtx = context.WithValue(jobCtx, leaseKey{}, lease)
+
+	if w.config.HeartbeatInterval > 0 {
+		go lease.heartbeat(jobCtx, w.config.HeartbeatInterval)
+	}
+
+	// Record the attempt; the store numbers it after the job's earlier ones
 	attempt := &models.JobAttempt{
-		ID:            fmt.Sprintf("%s-%d", job.ID, time.Now().UnixNano()),
-		JobID:         job.ID,
-		AttemptNumber: 1, // TODO: Get actual attempt number
-		StartedAt:     time.Now(),
-		Status:        models.StatusProcessing,
+		ID:        uuid.New().String(),
+		JobID:     job.ID,
+		WorkerID:  w.id,
+		StartedAt: time.Now(),
+		Status:    models.StatusProcessing,
 	}
 
 	err := w.repo.CreateJobAttempt(ctx, attempt)
@@ -136,31 +141,40 @@ func (w *Worker) processJob(ctx context.Context, job *models.Job) error {
 	}
 
 	// Execute the job handler
-	err = w.jobHandler(ctx, job)
-	if err != nil {
-		attempt.Status = models.StatusFailed
-		attempt.ErrorMessage = err.Error()
-		attempt.CompletedAt = &time.Time{}
-
-		// Update job attempt
-		updateErr := w.repo.CreateJobAttempt(ctx, attempt)
-		if updateErr != nil {
-			return fmt.Errorf("failed to update job attempt: %w", updateErr)
-		}
+	handlerErr := w.jobHandler(jobCtx, job)
 
-		// Handle retry logic
-		return w.handleJobFailure(ctx, job, err)
+	completedAt := time.Now()
+	attempt.CompletedAt = &completedAt
+	attempt.DurationMs = completedAt.Sub(attempt.StartedAt).Milliseconds()
+	attempt.Status = models.StatusCompleted
+	var snooze *snoozeError
+	if errors.As(handlerErr, &snooze) {
+		attempt.Status = models.StatusSnoozed
+		attempt.ErrorMessage = handlerErr.Error()
+	} else if handlerErr != nil {
+		attempt.Status = failureStatus(handlerErr)
+		attempt.ErrorMessage = handlerErr.Error()
 	}
 
-	// Job succeeded
-	attempt.Status = models.StatusCompleted
-	attempt.CompletedAt = &time.Time{}
-	err = w.repo.CreateJobAttempt(ctx, attempt)
+	err = w.repo.UpdateJobAttempt(ctx, attempt)
 	if err != nil {
 		return fmt.Errorf("failed to update job attempt: %w", err)
 	}
 
-	err = w.repo.UpdateJobStatus(ctx, job.ID, models.StatusCompleted)
+	if snooze != nil {
+		return w.snoozeJob(ctx, job, snooze.delay)
+	}
+	if handlerErr != nil {
+		// Handle retry logic
+		return w.handleJobFailure(ctx, job, handlerErr)
+	}
+
+	// Job succeeded
+	err = w.repo.FinishJob(ctx, job.ID, job.LeaseToken, models.StatusCompleted, "")
+	if errors.Is(err, ErrLeaseLost) {
+		log.Printf("Job %s: lease lost before completion, dropping result", job.ID)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update job status: %w", err)
 	}
@@ -178,65 +192,118 @@ func (w *Worker) processJob(ctx context.Context, job *models.Job) error {
 }
 
 func (w *Worker) handleJobFailure(ctx context.Context, job *models.Job, err error) error {
-	// Update job status to failed
-	updateErr := w.repo.UpdateJobStatus(ctx, job.ID, models.StatusFailed)
+	// A missing handler will not appear on retry, so fail permanently
+	attempts := job.CountedAttempts()
+	retry := job.IsRetryable() && !errors.Is(err, ErrNoHandler) && !errors.Is(err, SkipRetry) &&
+		job.ShouldRetry(attempts) && w.config.RetryStrategy.allows(attempts)
+	var delay time.Duration
+	var retryAfter *retryAfterError
+	if retry && errors.As(err, &retryAfter) {
+		delay = retryAfter.delay
+	} else if retry {
+		delay = w.calculateRetryDelay(ctx, job)
+	}
+	runAt := time.Now().Add(delay)
+
+	var updateErr error
+	if retry {
+		// Reset job to pending with new run_at time
+		updateErr = w.repo.UpdateJobForRetry(ctx, job.ID, job.LeaseToken, runAt, err.Error())
+	} else {
+		// Out of retries: dead-letter the job
+		updateErr = w.repo.FinishJob(ctx, job.ID, job.LeaseToken, models.StatusDead, err.Error())
+	}
+	if errors.Is(updateErr, ErrLeaseLost) {
+		log.Printf("Job %s: lease lost before failure was recorded, dropping result", job.ID)
+		return nil
+	}
 	if updateErr != nil {
 		return fmt.Errorf("failed to update job status: %w", updateErr)
 	}
 
+	var reason string
+	if status := failureStatus(err); status != models.StatusFailed {
+		reason = string(status)
+	}
+
 	// Send job failed event
-	w.eventChan <- models.JobEvent{
+	failed := models.JobEvent{
 		Type:      "failed",
 		JobID:     job.ID,
 		Queue:     job.Queue,
 		Timestamp: time.Now(),
 		Payload:   job.Payload,
 		Error:     err.Error(),
+		Reason:    reason,
+	}
+	if retry {
+		failed.RunAt = &runAt
 	}
+	w.eventChan <- failed
 
-	// Check if we should retry
-	if job.IsRetryable() {
-		// Get current attempts
-		attempts, err := w.repo.GetJobAttempts(ctx, job.ID)
-		if err != nil {
-			return fmt.Errorf("failed to get job attempts: %w", err)
-		}
+	if retry {
+		log.Printf("Job %s will be retried in %v", job.ID, delay)
+		return nil
+	}
+
+	log.Printf("Job %s is dead: %v", job.ID, err)
+
+	w.eventChan <- models.JobEvent{
+		Type:      "dead",
+		JobID:     job.ID,
+		Queue:     job.Queue,
+		Timestamp: time.Now(),
+		Payload:   job.Payload,
+		Error:     err.Error(),
+		Reason:    reason,
+	}
 
-		currentAttempts := len(attempts)
-		if job.ShouldRetry(currentAttempts) {
-			// Calculate retry delay
-			delay := w.calculateRetryDelay(currentAttempts)
+	return nil
+}
 
-			// Reset job to pending with new run_at time
-			job.Status = models.StatusPending
-			job.RunAt = time.Now().Add(delay)
+// snoozeJob reschedules a job whose handler returned Snooze. The attempt
+// is not counted against the job's retries.
+func (w *Worker) snoozeJob(ctx context.Context, job *models.Job, delay time.Duration) error {
+	runAt := time.Now().Add(delay)
+	err := w.repo.SnoozeJob(ctx, job.ID, job.LeaseToken, runAt)
+	if errors.Is(err, ErrLeaseLost) {
+		log.Printf("Job %s: lease lost before snooze was recorded, dropping result", job.ID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to snooze job: %w", err)
+	}
 
-			// Update job in database with new run_at and reset status
-			err := w.repo.UpdateJobForRetry(ctx, job.ID, job.RunAt)
-			if err != nil {
-				return fmt.Errorf("failed to update job for retry: %w", err)
-			}
+	log.Printf("Job %s snoozed for %v", job.ID, delay)
 
-			log.Printf("Job %s will be retried in %v", job.ID, delay)
-			return nil
-		}
+	w.eventChan <- models.JobEvent{
+		Type:      "snoozed",
+		JobID:     job.ID,
+		Queue:     job.Queue,
+		Timestamp: time.Now(),
+		Payload:   job.Payload,
+		RunAt:     &runAt,
 	}
 
 	return nil
 }
 
-func (w *Worker) calculateRetryDelay(attempt int) time.Duration {
-	switch w.config.RetryStrategy.Type {
-	case "exponential":
-		exponent := math.Min(float64(attempt), 10) // Cap exponent at 10
-		return time.Duration(math.Pow(2, exponent)) * time.Second * time.Duration(w.config.RetryStrategy.Interval)
-	case "fixed":
-		return time.Duration(w.config.RetryStrategy.Interval) * time.Second
-	default:
-		return time.Duration(w.config.RetryStrategy.Interval) * time.Second
+// calculateRetryDelay layers the job's retry policy over its queue's, and
+// that over the server default.
+func (w *Worker) calculateRetryDelay(ctx context.Context, job *models.Job) time.Duration {
+	policy := w.config.RetryStrategy.policy()
+
+	queue, err := w.repo.GetQueue(ctx, job.Queue)
+	if err != nil {
+		log.Printf("Failed to load retry policy for queue %s: %v", job.Queue, err)
+	} else if queue != nil && queue.RetryPolicy != nil {
+		policy = queue.RetryPolicy.Or(policy)
+	}
+	if job.RetryPolicy != nil {
+		policy = job.RetryPolicy.Or(policy)
 	}
-}
 
-func (w *Worker) Shutdown() {
-	close(w.shutdownCh)
+	// Snoozed runs aside, the job has run CountedAttempts times, so this is
+	// retry number CountedAttempts
+	return policy.Delay(job.CountedAttempts(), rand.Float64())
 }
@@ -0,0 +1,229 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/repository"
+)
+
+type testWorker struct {
+	*Worker
+	repo   *repository.MemoryRepository
+	events chan models.JobEvent
+}
+
+// newTestWorker returns a worker on an in-memory store whose retries wait
+// for interval.
+func newTestWorker(handler JobHandler, interval time.Duration) *testWorker {
+	repo := repository.NewMemoryRepository()
+	events := make(chan models.JobEvent, 64)
+	config := WorkerConfig{
+		VisibilityTimeout: time.Minute,
+		RetryStrategy:     RetryStrategy{Type: models.RetryFixed, Interval: int(interval / time.Second)},
+	}
+	return &testWorker{
+		Worker: NewWorker("worker-1", repo, handler, config, events),
+		repo:   repo,
+		events: events,
+	}
+}
+
+func (w *testWorker) enqueue(t *testing.T, maxRetries int) *models.Job {
+	t.Helper()
+
+	job := &models.Job{
+		ID:         uuid.New().String(),
+		Queue:      "email",
+		Type:       "test",
+		Payload:    json.RawMessage(`{}`),
+		MaxRetries: maxRetries,
+		RunAt:      time.Now().Add(-time.Second),
+		Status:     models.StatusPending,
+		Priority:   models.PriorityNormal,
+	}
+	if _, err := w.repo.CreateJob(context.Background(), job, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// runNext claims the next due job and runs it, returning false if no job
+// was due.
+func (w *testWorker) runNext(t *testing.T) bool {
+	t.Helper()
+
+	jobs, err := w.repo.PickJobs(context.Background(), w.id, nil, 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) == 0 {
+		return false
+	}
+	if err := w.runJob(context.Background(), jobs[0]); err != nil {
+		t.Fatalf("runJob() = %v", err)
+	}
+	return true
+}
+
+func (w *testWorker) job(t *testing.T, id string) *models.Job {
+	t.Helper()
+
+	job, err := w.repo.GetJobByID(context.Background(), id)
+	if err != nil || job == nil {
+		t.Fatalf("GetJobByID() = %v, %v", job, err)
+	}
+	return job
+}
+
+func (w *testWorker) attemptStatuses(t *testing.T, id string) []models.JobStatus {
+	t.Helper()
+
+	attempts, err := w.repo.GetJobAttempts(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []models.JobStatus
+	for i, attempt := range attempts {
+		if attempt.AttemptNumber != i+1 {
+			t.Errorf("attempt %d is numbered %d", i+1, attempt.AttemptNumber)
+		}
+		statuses = append(statuses, attempt.Status)
+	}
+	return statuses
+}
+
+// eventTypes drains the events sent so far. Failure events are listed with
+// their reason, if any.
+func (w *testWorker) eventTypes() []string {
+	var types []string
+	for {
+		select {
+		case event := <-w.events:
+			if event.Reason != "" {
+				types = append(types, event.Type+":"+event.Reason)
+			} else {
+				types = append(types, event.Type)
+			}
+		default:
+			return types
+		}
+	}
+}
+
+func TestFailureGoesDeadAfterMaxRetries(t *testing.T) {
+	w := newTestWorker(func(ctx context.Context, job *models.Job) error {
+		return fmt.Errorf("smtp unavailable")
+	}, 0)
+	job := w.enqueue(t, 2)
+
+	for range 3 {
+		if !w.runNext(t) {
+			t.Fatal("job was not due for another run")
+		}
+	}
+	if w.runNext(t) {
+		t.Fatal("job ran again after going dead")
+	}
+
+	stored := w.job(t, job.ID)
+	if stored.Status != models.StatusDead || stored.Attempts != 3 || stored.Snoozes != 0 || stored.LastError != "smtp unavailable" {
+		t.Errorf("job is %s after %d attempts and %d snoozes with error %q, want dead after 3 and none",
+			stored.Status, stored.Attempts, stored.Snoozes, stored.LastError)
+	}
+	if got, want := w.attemptStatuses(t, job.ID), []models.JobStatus{models.StatusFailed, models.StatusFailed, models.StatusFailed}; !slices.Equal(got, want) {
+		t.Errorf("attempts = %v, want %v", got, want)
+	}
+	want := []string{"started", "failed", "started", "failed", "started", "failed", "dead"}
+	if got := w.eventTypes(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestSnoozeDoesNotUseARetry(t *testing.T) {
+	runs := 0
+	w := newTestWorker(func(ctx context.Context, job *models.Job) error {
+		runs++
+		switch runs {
+		case 1, 2:
+			return Snooze(0)
+		case 3:
+			return fmt.Errorf("timeout")
+		}
+		return nil
+	}, 0)
+	job := w.enqueue(t, 1)
+
+	for w.runNext(t) {
+	}
+
+	stored := w.job(t, job.ID)
+	if stored.Status != models.StatusCompleted || stored.Attempts != 4 || stored.Snoozes != 2 {
+		t.Errorf("job is %s after %d attempts and %d snoozes, want completed after 4 and 2",
+			stored.Status, stored.Attempts, stored.Snoozes)
+	}
+	want := []models.JobStatus{models.StatusSnoozed, models.StatusSnoozed, models.StatusFailed, models.StatusCompleted}
+	if got := w.attemptStatuses(t, job.ID); !slices.Equal(got, want) {
+		t.Errorf("attempts = %v, want %v", got, want)
+	}
+	wantEvents := []string{"started", "snoozed", "started", "snoozed", "started", "failed", "started", "succeeded"}
+	if got := w.eventTypes(); !slices.Equal(got, wantEvents) {
+		t.Errorf("events = %v, want %v", got, wantEvents)
+	}
+}
+
+func TestSkipRetryGoesStraightToDead(t *testing.T) {
+	w := newTestWorker(func(ctx context.Context, job *models.Job) error {
+		return fmt.Errorf("malformed address: %w", SkipRetry)
+	}, 0)
+	job := w.enqueue(t, 5)
+
+	w.runNext(t)
+	if w.runNext(t) {
+		t.Fatal("job was retried after SkipRetry")
+	}
+
+	stored := w.job(t, job.ID)
+	if stored.Status != models.StatusDead || stored.Attempts != 1 {
+		t.Errorf("job is %s after %d attempts, want dead after 1", stored.Status, stored.Attempts)
+	}
+	if got, want := w.attemptStatuses(t, job.ID), []models.JobStatus{models.StatusSkipped}; !slices.Equal(got, want) {
+		t.Errorf("attempts = %v, want %v", got, want)
+	}
+	want := []string{"started", "failed:skipped", "dead:skipped"}
+	if got := w.eventTypes(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestRetryAfterOverridesBackoff(t *testing.T) {
+	w := newTestWorker(func(ctx context.Context, job *models.Job) error {
+		return RetryAfter(2 * time.Minute)
+	}, time.Hour)
+	job := w.enqueue(t, 3)
+
+	before := time.Now()
+	w.runNext(t)
+
+	stored := w.job(t, job.ID)
+	if stored.Status != models.StatusPending || stored.Attempts != 1 {
+		t.Errorf("job is %s after %d attempts, want pending after 1", stored.Status, stored.Attempts)
+	}
+	if wait := stored.RunAt.Sub(before); wait < 2*time.Minute || wait > 3*time.Minute {
+		t.Errorf("job runs again in %v, want 2m rather than the 1h backoff", wait)
+	}
+	if got, want := w.attemptStatuses(t, job.ID), []models.JobStatus{models.StatusRetryAfter}; !slices.Equal(got, want) {
+		t.Errorf("attempts = %v, want %v", got, want)
+	}
+	want := []string{"started", "failed:retry_after"}
+	if got := w.eventTypes(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,10 @@
+// Package migrations embeds the schema files that are applied
+// automatically at startup.
+package migrations
+
+import "embed"
+
+// SQLite holds the SQLite schema, applied in file name order.
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
@@ -0,0 +1,4 @@
+-- Add job type used to route jobs to typed handlers
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS type VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs(type);
@@ -0,0 +1,6 @@
+-- Create queues table holding cluster-wide queue state
+CREATE TABLE IF NOT EXISTS queues (
+    name VARCHAR(255) PRIMARY KEY,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,19 @@
+-- Create schedules table for recurring jobs
+CREATE TABLE IF NOT EXISTS schedules (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    cron VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    queue VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL DEFAULT '',
+    payload JSONB NOT NULL,
+    priority VARCHAR(50) NOT NULL DEFAULT 'normal',
+    max_retries INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_next_run_at ON schedules(next_run_at) WHERE enabled;
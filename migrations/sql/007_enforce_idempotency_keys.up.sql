@@ -0,0 +1,13 @@
+-- Enforce idempotency keys per queue. Empty keys become NULL and, where a
+-- key is already duplicated, only the oldest job keeps it.
+UPDATE jobs SET idempotency_key = NULL WHERE idempotency_key = '';
+
+UPDATE jobs j
+SET idempotency_key = NULL
+FROM jobs o
+WHERE o.queue = j.queue
+AND o.idempotency_key = j.idempotency_key
+AND (o.created_at, o.id) < (j.created_at, j.id);
+
+DROP INDEX IF EXISTS idx_jobs_idempotency;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_queue_idempotency ON jobs(queue, idempotency_key) WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Track when a worker's claim on a processing job expires
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ;
+
+-- Jobs already processing get the default 30 second visibility timeout
+UPDATE jobs
+SET lease_expires_at = COALESCE(locked_at, updated_at) + INTERVAL '30 seconds'
+WHERE status = 'processing' AND lease_expires_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_lease_expires_at ON jobs(lease_expires_at) WHERE status = 'processing';
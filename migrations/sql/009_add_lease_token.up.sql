@@ -0,0 +1,2 @@
+-- Fencing token bumped on every pickup; completion updates must present it
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS lease_token BIGINT NOT NULL DEFAULT 0;
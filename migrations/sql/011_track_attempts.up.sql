@@ -0,0 +1,7 @@
+-- Count executions on the job itself; each pick takes the next number
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+
+UPDATE jobs
+SET attempts = (SELECT COALESCE(MAX(attempt_number), 0) FROM job_attempts WHERE job_id = jobs.id);
+
+ALTER TABLE job_attempts ADD COLUMN IF NOT EXISTS duration_ms BIGINT;
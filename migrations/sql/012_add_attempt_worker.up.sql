@@ -0,0 +1,2 @@
+-- Record which worker ran each attempt
+ALTER TABLE job_attempts ADD COLUMN IF NOT EXISTS worker_id VARCHAR(255);
@@ -0,0 +1,3 @@
+-- Retry policy overrides set on enqueue or per queue
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS retry_policy JSONB;
+ALTER TABLE queues ADD COLUMN IF NOT EXISTS retry_policy JSONB;
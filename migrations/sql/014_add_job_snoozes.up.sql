@@ -0,0 +1,2 @@
+-- Snoozed runs are counted apart so they do not use up retries
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS snoozes INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,38 @@
+-- Wake listening dispatchers when a job becomes ready to run. Each
+-- notification goes to the shared channel and to the queue's own channel,
+-- named after the md5 of the queue so any queue name fits.
+CREATE OR REPLACE FUNCTION gosynq_notify_queue(queue TEXT) RETURNS VOID AS $$
+BEGIN
+    PERFORM pg_notify('gosynq_jobs', '');
+    PERFORM pg_notify('gosynq_jobs_' || md5(queue), '');
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION gosynq_notify_job() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM gosynq_notify_queue(NEW.queue);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION gosynq_notify_resumed_queue() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM gosynq_notify_queue(NEW.name);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Covers enqueues, retries, snoozes, requeues and reclaimed leases
+DROP TRIGGER IF EXISTS jobs_notify_ready ON jobs;
+CREATE TRIGGER jobs_notify_ready
+    AFTER INSERT OR UPDATE OF status, run_at ON jobs
+    FOR EACH ROW
+    WHEN (NEW.status = 'pending' AND NEW.run_at <= NOW())
+    EXECUTE FUNCTION gosynq_notify_job();
+
+DROP TRIGGER IF EXISTS queues_notify_resumed ON queues;
+CREATE TRIGGER queues_notify_resumed
+    AFTER UPDATE OF paused ON queues
+    FOR EACH ROW
+    WHEN (OLD.paused AND NOT NEW.paused)
+    EXECUTE FUNCTION gosynq_notify_resumed_queue();
@@ -0,0 +1,37 @@
+-- Create jobs table
+CREATE TABLE IF NOT EXISTS jobs (
+    id TEXT PRIMARY KEY,
+    queue TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    payload BLOB NOT NULL,
+    max_retries INTEGER NOT NULL DEFAULT 0,
+    run_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    priority TEXT NOT NULL DEFAULT 'normal',
+    idempotency_key TEXT,
+    locked_by TEXT,
+    locked_at TIMESTAMP
+);
+
+-- Create job_attempts table
+CREATE TABLE IF NOT EXISTS job_attempts (
+    id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    attempt_number INTEGER NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    status TEXT NOT NULL,
+    error_message TEXT,
+    UNIQUE(job_id, attempt_number)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
+CREATE INDEX IF NOT EXISTS idx_jobs_queue ON jobs(queue);
+CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs(type);
+CREATE INDEX IF NOT EXISTS idx_jobs_priority ON jobs(priority);
+CREATE INDEX IF NOT EXISTS idx_jobs_run_at ON jobs(run_at);
+CREATE INDEX IF NOT EXISTS idx_jobs_idempotency ON jobs(idempotency_key) WHERE idempotency_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_job_attempts_job_id ON job_attempts(job_id);
@@ -0,0 +1,5 @@
+-- Add weighted selection and concurrency limits to queues
+ALTER TABLE queues ADD COLUMN weight INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE queues ADD COLUMN max_in_flight INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queue_status ON jobs(queue, status);
@@ -0,0 +1,15 @@
+-- Enforce idempotency keys per queue. Empty keys become NULL and, where a
+-- key is already duplicated, only the oldest job keeps it.
+UPDATE jobs SET idempotency_key = NULL WHERE idempotency_key = '';
+
+UPDATE jobs
+SET idempotency_key = NULL
+WHERE EXISTS (
+    SELECT 1 FROM jobs o
+    WHERE o.queue = jobs.queue
+    AND o.idempotency_key = jobs.idempotency_key
+    AND (o.created_at < jobs.created_at OR (o.created_at = jobs.created_at AND o.id < jobs.id))
+);
+
+DROP INDEX IF EXISTS idx_jobs_idempotency;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_queue_idempotency ON jobs(queue, idempotency_key) WHERE idempotency_key IS NOT NULL;
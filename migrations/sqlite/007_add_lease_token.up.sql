@@ -0,0 +1,2 @@
+-- Fencing token bumped on every pickup; completion updates must present it
+ALTER TABLE jobs ADD COLUMN lease_token INTEGER NOT NULL DEFAULT 0;
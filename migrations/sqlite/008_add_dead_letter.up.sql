@@ -0,0 +1,5 @@
+-- Keep the error a job last failed with
+ALTER TABLE jobs ADD COLUMN last_error TEXT;
+
+-- Failed jobs have no retries left, which is now the dead status
+UPDATE jobs SET status = 'dead' WHERE status = 'failed';
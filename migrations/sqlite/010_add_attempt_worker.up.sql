@@ -0,0 +1,2 @@
+-- Record which worker ran each attempt
+ALTER TABLE job_attempts ADD COLUMN worker_id TEXT;
@@ -0,0 +1,3 @@
+-- Retry policy overrides set on enqueue or per queue
+ALTER TABLE jobs ADD COLUMN retry_policy TEXT;
+ALTER TABLE queues ADD COLUMN retry_policy TEXT;
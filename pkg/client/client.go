@@ -0,0 +1,344 @@
+// Package client is a Go client for the gosynq REST and WebSocket API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/models"
+)
+
+type (
+	Job            = models.Job
+	Attempt        = models.JobAttempt
+	Schedule       = models.Schedule
+	JobStatus      = models.JobStatus
+	JobPriority    = models.JobPriority
+	Event          = models.JobEvent
+	EnqueueRequest = models.EnqueueJobRequest
+	RetryPolicy    = models.RetryPolicy
+	Duration       = models.Duration
+)
+
+const (
+	StatusPending    = models.StatusPending
+	StatusProcessing = models.StatusProcessing
+	StatusCompleted  = models.StatusCompleted
+	StatusCancelled  = models.StatusCancelled
+	StatusDead       = models.StatusDead
+
+	// StatusScheduled is only valid as a ListJobs filter: it selects
+	// pending jobs whose run_at is still in the future, soonest first.
+	StatusScheduled JobStatus = "scheduled"
+
+	PriorityLow    = models.PriorityLow
+	PriorityNormal = models.PriorityNormal
+	PriorityHigh   = models.PriorityHigh
+)
+
+// EnqueueResult is the server's answer to an enqueue request. Batch
+// results for items the server rejected have status "invalid" and carry
+// the reason in Error.
+type EnqueueResult struct {
+	JobID  string    `json:"job_id"`
+	Status string    `json:"status"`
+	RunAt  time.Time `json:"run_at"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// ListOptions filters ListJobs. Empty fields match everything.
+type ListOptions struct {
+	Status JobStatus
+	Queue  string
+	Type   string
+	Limit  int
+}
+
+// DeadSelector picks dead jobs for RequeueDead and PurgeDead. Empty fields
+// match every dead job.
+type DeadSelector struct {
+	IDs   []string `json:"ids,omitempty"`
+	Queue string   `json:"queue,omitempty"`
+}
+
+// ScheduleRequest is the body of CreateSchedule and UpdateSchedule. A nil
+// Enabled means enabled on create and unchanged on update.
+type ScheduleRequest struct {
+	Name       string          `json:"name"`
+	Cron       string          `json:"cron"`
+	Timezone   string          `json:"timezone,omitempty"`
+	Queue      string          `json:"queue"`
+	Type       string          `json:"type,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Priority   JobPriority     `json:"priority,omitempty"`
+	MaxRetries int             `json:"max_retries,omitempty"`
+	Enabled    *bool           `json:"enabled,omitempty"`
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	username   string
+	password   string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default HTTP client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAdminCredentials sets the basic auth credentials used for admin
+// endpoints such as Retry and Cancel.
+func WithAdminCredentials(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// New creates a client for the gosynq server at baseURL, for example
+// "http://localhost:8080". The /api/v1 prefix is added by the client.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/") + "/api/v1",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Enqueue submits a single job. Set RunAt or RunIn to delay it. If
+// IdempotencyKey is already taken in the queue, no job is created and the
+// result carries the original job's ID with status "duplicate".
+func (c *Client) Enqueue(ctx context.Context, req EnqueueRequest) (*EnqueueResult, error) {
+	var result EnqueueResult
+	if err := c.do(ctx, http.MethodPost, "/jobs", nil, req, false, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// EnqueueBatch submits jobs in a single request, which the server stores
+// in one transaction. The results are in the same order as reqs. Invalid
+// jobs are skipped and reported in their result without failing the rest
+// of the batch.
+func (c *Client) EnqueueBatch(ctx context.Context, reqs []EnqueueRequest) ([]EnqueueResult, error) {
+	var resp struct {
+		Results []EnqueueResult `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/jobs/batch", nil, reqs, false, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// GetJob fetches a job by ID. It returns an error matching ErrNotFound when
+// the job does not exist.
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id), nil, nil, false, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobAttempts returns a job's attempts, oldest first. It returns an error
+// matching ErrNotFound when the job does not exist.
+func (c *Client) GetJobAttempts(ctx context.Context, id string) ([]*Attempt, error) {
+	var attempts []*Attempt
+	if err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id)+"/attempts", nil, nil, false, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// ListJobs lists jobs, newest first.
+func (c *Client) ListJobs(ctx context.Context, opts ListOptions) ([]*Job, error) {
+	query := url.Values{}
+	if opts.Status != "" {
+		query.Set("status", string(opts.Status))
+	}
+	if opts.Queue != "" {
+		query.Set("queue", opts.Queue)
+	}
+	if opts.Type != "" {
+		query.Set("type", opts.Type)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	var jobs []*Job
+	if err := c.do(ctx, http.MethodGet, "/jobs", query, nil, false, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Retry requeues a dead job to run now with its attempts reset. Requires
+// admin credentials.
+func (c *Client) Retry(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/admin/jobs/"+url.PathEscape(id)+"/retry", nil, nil, true, nil)
+}
+
+// Cancel cancels a job. Requires admin credentials.
+func (c *Client) Cancel(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/admin/jobs/"+url.PathEscape(id)+"/cancel", nil, nil, true, nil)
+}
+
+// PauseQueue stops workers on every node from picking jobs in queue.
+// Requires admin credentials.
+func (c *Client) PauseQueue(ctx context.Context, queue string) error {
+	return c.do(ctx, http.MethodPost, "/admin/queues/"+url.PathEscape(queue)+"/pause", nil, nil, true, nil)
+}
+
+// ResumeQueue resumes a paused queue. Requires admin credentials.
+func (c *Client) ResumeQueue(ctx context.Context, queue string) error {
+	return c.do(ctx, http.MethodPost, "/admin/queues/"+url.PathEscape(queue)+"/resume", nil, nil, true, nil)
+}
+
+// ListDead lists jobs that ran out of retries, newest first. Only Queue,
+// Type and Limit in opts are used. Requires admin credentials.
+func (c *Client) ListDead(ctx context.Context, opts ListOptions) ([]*Job, error) {
+	query := url.Values{}
+	if opts.Queue != "" {
+		query.Set("queue", opts.Queue)
+	}
+	if opts.Type != "" {
+		query.Set("type", opts.Type)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	var jobs []*Job
+	if err := c.do(ctx, http.MethodGet, "/admin/dead", query, nil, true, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// RequeueDead returns the selected dead jobs to pending and reports how many
+// were requeued. Requires admin credentials.
+func (c *Client) RequeueDead(ctx context.Context, sel DeadSelector) (int, error) {
+	var resp struct {
+		Requeued int `json:"requeued"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/admin/dead/requeue", nil, sel, true, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Requeued, nil
+}
+
+// PurgeDead deletes the selected dead jobs and reports how many were
+// deleted. Requires admin credentials.
+func (c *Client) PurgeDead(ctx context.Context, sel DeadSelector) (int, error) {
+	var resp struct {
+		Purged int `json:"purged"`
+	}
+	if err := c.do(ctx, http.MethodDelete, "/admin/dead", nil, sel, true, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Purged, nil
+}
+
+// ListSchedules lists the cron schedules.
+func (c *Client) ListSchedules(ctx context.Context) ([]*Schedule, error) {
+	var schedules []*Schedule
+	if err := c.do(ctx, http.MethodGet, "/schedules", nil, nil, false, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// GetSchedule fetches a schedule by ID. It returns an error matching
+// ErrNotFound when the schedule does not exist.
+func (c *Client) GetSchedule(ctx context.Context, id string) (*Schedule, error) {
+	var schedule Schedule
+	if err := c.do(ctx, http.MethodGet, "/schedules/"+url.PathEscape(id), nil, nil, false, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// CreateSchedule creates a cron schedule. Requires admin credentials.
+func (c *Client) CreateSchedule(ctx context.Context, req ScheduleRequest) (*Schedule, error) {
+	var schedule Schedule
+	if err := c.do(ctx, http.MethodPost, "/schedules", nil, req, true, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// UpdateSchedule replaces a schedule. Requires admin credentials.
+func (c *Client) UpdateSchedule(ctx context.Context, id string, req ScheduleRequest) (*Schedule, error) {
+	var schedule Schedule
+	if err := c.do(ctx, http.MethodPut, "/schedules/"+url.PathEscape(id), nil, req, true, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// DeleteSchedule deletes a schedule. Requires admin credentials.
+func (c *Client) DeleteSchedule(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/schedules/"+url.PathEscape(id), nil, nil, true, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, admin bool, out interface{}) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if admin {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newAPIError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		kind    error
+		message string
+	}{
+		{"bad request", http.StatusBadRequest, `{"error":"payload is required"}`, ErrBadRequest, "payload is required"},
+		{"unauthorized", http.StatusUnauthorized, ``, ErrUnauthorized, ""},
+		{"not found", http.StatusNotFound, `{"error":"job not found"}`, ErrNotFound, "job not found"},
+		{"server error", http.StatusInternalServerError, `{"error":"database is down"}`, ErrServer, "database is down"},
+		{"unavailable", http.StatusServiceUnavailable, `{"error":"dispatcher is shut down"}`, ErrServer, "dispatcher is shut down"},
+		{"plain text body", http.StatusBadGateway, `upstream failed`, ErrServer, "upstream failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			_, err := New(ts.URL).GetJob(context.Background(), "job-1")
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("GetJob() = %v, want an error matching %v", err, tt.kind)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("GetJob() = %T, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.message {
+				t.Errorf("APIError = %d %q, want %d %q", apiErr.StatusCode, apiErr.Message, tt.status, tt.message)
+			}
+			for _, other := range []error{ErrBadRequest, ErrUnauthorized, ErrNotFound, ErrServer} {
+				if other != tt.kind && errors.Is(err, other) {
+					t.Errorf("GetJob() also matches %v", other)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminRequestsSendCredentials(t *testing.T) {
+	var gotPath, gotUser, gotPassword string
+	var gotAuth bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		gotUser, gotPassword, gotAuth = r.BasicAuth()
+		json.NewEncoder(w).Encode(map[string]any{"id": "job-1"})
+	}))
+	defer ts.Close()
+
+	c := New(ts.URL, WithAdminCredentials("admin", "secret"))
+
+	if err := c.Retry(context.Background(), "job-1"); err != nil {
+		t.Fatalf("Retry() = %v", err)
+	}
+	if gotPath != "POST /api/v1/admin/jobs/job-1/retry" {
+		t.Errorf("request = %s, want POST /api/v1/admin/jobs/job-1/retry", gotPath)
+	}
+	if !gotAuth || gotUser != "admin" || gotPassword != "secret" {
+		t.Errorf("basic auth = %q %q %v, want admin secret", gotUser, gotPassword, gotAuth)
+	}
+
+	job, err := c.GetJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("GetJob() = %v", err)
+	}
+	if job.ID != "job-1" {
+		t.Errorf("job ID = %q, want job-1", job.ID)
+	}
+	if gotAuth {
+		t.Error("GetJob() sent admin credentials to a public endpoint")
+	}
+}
+
+func TestScheduleRequests(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, auth := r.BasicAuth()
+		requests = append(requests, fmt.Sprintf("%s %s %v", r.Method, r.URL.Path, auth))
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/schedules" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"id": "schedule-1", "name": "nightly"})
+	}))
+	defer ts.Close()
+
+	c := New(ts.URL, WithAdminCredentials("admin", "secret"))
+	ctx := context.Background()
+
+	if _, err := c.ListSchedules(ctx); err != nil {
+		t.Fatalf("ListSchedules() = %v", err)
+	}
+	if _, err := c.GetSchedule(ctx, "schedule-1"); err != nil {
+		t.Fatalf("GetSchedule() = %v", err)
+	}
+	schedule, err := c.CreateSchedule(ctx, ScheduleRequest{Name: "nightly", Cron: "@daily", Queue: "reports"})
+	if err != nil {
+		t.Fatalf("CreateSchedule() = %v", err)
+	}
+	if schedule.ID != "schedule-1" {
+		t.Errorf("schedule ID = %q, want schedule-1", schedule.ID)
+	}
+	if _, err := c.UpdateSchedule(ctx, "schedule-1", ScheduleRequest{Name: "nightly", Cron: "@hourly", Queue: "reports"}); err != nil {
+		t.Fatalf("UpdateSchedule() = %v", err)
+	}
+	if err := c.DeleteSchedule(ctx, "schedule-1"); err != nil {
+		t.Fatalf("DeleteSchedule() = %v", err)
+	}
+
+	want := []string{
+		"GET /api/v1/schedules false",
+		"GET /api/v1/schedules/schedule-1 false",
+		"POST /api/v1/schedules true",
+		"PUT /api/v1/schedules/schedule-1 true",
+		"DELETE /api/v1/schedules/schedule-1 true",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Subscription streams job events from the server's WebSocket endpoint.
+type Subscription struct {
+	conn   *websocket.Conn
+	events chan Event
+	done   chan struct{}
+	mu     sync.Mutex
+	err    error
+	once   sync.Once
+}
+
+// Subscribe opens a WebSocket connection and streams job lifecycle events
+// until ctx is cancelled, Close is called or the connection drops.
+func (c *Client) Subscribe(ctx context.Context) (*Subscription, error) {
+	wsURL := c.baseURL + "/ws"
+	switch {
+	case strings.HasPrefix(wsURL, "https://"):
+		wsURL = "wss://" + strings.TrimPrefix(wsURL, "https://")
+	case strings.HasPrefix(wsURL, "http://"):
+		wsURL = "ws://" + strings.TrimPrefix(wsURL, "http://")
+	}
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= http.StatusBadRequest {
+			return nil, newAPIError(resp)
+		}
+		return nil, fmt.Errorf("failed to connect to event stream: %w", err)
+	}
+
+	sub := &Subscription{
+		conn:   conn,
+		events: make(chan Event, 64),
+		done:   make(chan struct{}),
+	}
+	go sub.readLoop()
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Close()
+		case <-sub.done:
+		}
+	}()
+
+	return sub, nil
+}
+
+// Events returns the event channel. It is closed when the subscription ends;
+// Err then reports why.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns the error that ended the subscription, if any.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		err = s.conn.Close()
+	})
+	return err
+}
+
+func (s *Subscription) readLoop() {
+	defer close(s.events)
+	defer s.Close()
+
+	for {
+		var event Event
+		if err := s.conn.ReadJSON(&event); err != nil {
+			if !errors.Is(err, net.ErrClosed) &&
+				!websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+			}
+			return
+		}
+
+		select {
+		case s.events <- event:
+		case <-s.done:
+			return
+		}
+	}
+}
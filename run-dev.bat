@@ -1,6 +1,9 @@
 @echo off
 
 echo Starting backend server...
+rem Development admin credentials, matching the frontend's environment.ts
+set GOSYNQ_ADMIN_USERNAME=admin
+set GOSYNQ_ADMIN_PASSWORD=password
 start /B cmd /C "go run cmd\server\main.go"
 
 echo Waiting for backend to start...
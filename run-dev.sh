@@ -2,7 +2,8 @@
 
 # Start backend in background
 echo "Starting backend server..."
-go run cmd/server/main.go &
+# Development admin credentials, matching the frontend's environment.ts
+GOSYNQ_ADMIN_USERNAME=admin GOSYNQ_ADMIN_PASSWORD=password go run cmd/server/main.go &
 
 # Get the backend process ID
 BACKEND_PID=$!
@@ -0,0 +1,462 @@
+// Package gosynq embeds the gosynq job system in another Go program.
+//
+// A Server owns the dispatcher, its worker pool, the WebSocket event hub and
+// the HTTP API. Run it alongside an existing service and either let it listen
+// on its own address or mount Handler under your own mux.
+package gosynq
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/arthures11/gosynq/internal/api"
+	"github.com/arthures11/gosynq/internal/config"
+	"github.com/arthures11/gosynq/internal/dispatcher"
+	"github.com/arthures11/gosynq/internal/models"
+	"github.com/arthures11/gosynq/internal/repository"
+	"github.com/arthures11/gosynq/internal/websocket"
+	"github.com/arthures11/gosynq/internal/worker"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	Job         = models.Job
+	JobStatus   = models.JobStatus
+	JobPriority = models.JobPriority
+	Config      = config.Config
+	HandlerFunc = worker.JobHandler
+	Store       = repository.Store
+	Schedule    = models.Schedule
+	RetryPolicy = models.RetryPolicy
+	Duration    = models.Duration
+)
+
+// ErrServerClosed is returned by Enqueue and EnqueueBatch after Shutdown.
+var ErrServerClosed = dispatcher.ErrClosed
+
+// ErrLeaseLost is returned by ExtendLease once the job has been reclaimed
+// by another worker.
+var ErrLeaseLost = worker.ErrLeaseLost
+
+// ExtendLease renews the lease on the job being handled under ctx for
+// another visibility timeout, so a long-running job is not reclaimed as
+// abandoned.
+func ExtendLease(ctx context.Context) error {
+	return worker.ExtendLease(ctx)
+}
+
+// SkipRetry, returned or wrapped by a handler, fails the job without
+// retrying it.
+var SkipRetry = worker.SkipRetry
+
+// Snooze returns a handler error that reschedules the job after d without
+// using up one of its retries.
+func Snooze(d time.Duration) error {
+	return worker.Snooze(d)
+}
+
+// RetryAfter returns a handler error that retries the job after d instead
+// of the delay from its retry policy.
+func RetryAfter(d time.Duration) error {
+	return worker.RetryAfter(d)
+}
+
+type Server struct {
+	cfg        *Config
+	db         *sql.DB
+	ownedDB    *sql.DB
+	httpAddr   string
+	registerer prometheus.Registerer
+	repo       Store
+	disp       *dispatcher.Dispatcher
+	wsServer   *websocket.WebSocketServer
+	handler    http.Handler
+	httpServer *http.Server
+
+	// registrations are deferred until the dispatcher exists
+	registrations []func(*dispatcher.Dispatcher)
+
+	startOnce    sync.Once
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+type Option func(*Server)
+
+// WithDB stores jobs in the PostgreSQL database behind db.
+func WithDB(db *sql.DB) Option {
+	return func(s *Server) {
+		s.db = db
+	}
+}
+
+// WithStore stores jobs in store instead of PostgreSQL, for example one
+// created with NewMemoryStore.
+func WithStore(store Store) Option {
+	return func(s *Server) {
+		s.repo = store
+	}
+}
+
+// NewMemoryStore returns a Store that keeps jobs in process memory. It is
+// meant for tests and local demos; jobs are lost on restart.
+func NewMemoryStore() Store {
+	return repository.NewMemoryRepository()
+}
+
+// WithConfig replaces the default configuration with a copy of cfg.
+// Options applied after it still override individual settings. Without
+// WithDB or WithStore the database described by cfg.Database is opened, and
+// closed on Shutdown.
+func WithConfig(cfg *Config) Option {
+	return func(s *Server) {
+		s.cfg = cfg.Clone()
+	}
+}
+
+// WithRegisterer registers the server's Prometheus metrics with reg, for
+// example prometheus.DefaultRegisterer. By default each server keeps them
+// in a registry of its own, so several servers can share a process.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(s *Server) {
+		s.registerer = reg
+	}
+}
+
+// WithAPIOnly makes Run serve only the HTTP API. No workers, scheduler or
+// reaper are started on this server, so jobs it enqueues are run by other
+// servers sharing its database, such as ones started by cmd/worker.
+func WithAPIOnly() Option {
+	return func(s *Server) {
+		s.cfg.Server.APIOnly = true
+	}
+}
+
+// WithAdminCredentials sets the basic auth credentials of the admin API.
+// Empty credentials disable it.
+func WithAdminCredentials(username, password string) Option {
+	return func(s *Server) {
+		s.cfg.Admin = config.AdminConfig{Username: username, Password: password}
+	}
+}
+
+// WithWorkerPoolSize sets the number of concurrent workers.
+func WithWorkerPoolSize(n int) Option {
+	return func(s *Server) {
+		s.cfg.Worker.PoolSize = n
+	}
+}
+
+// WithWorkerQueues restricts this server's workers to jobs in the given
+// queues, so different deployments can serve different queues against the
+// same database.
+func WithWorkerQueues(queues ...string) Option {
+	return func(s *Server) {
+		s.cfg.Worker.Queues = queues
+	}
+}
+
+// WithVisibilityTimeout sets how long a worker may hold a job. It must be
+// longer than the heartbeat interval, 10s unless set with
+// WithHeartbeatInterval.
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.cfg.Worker.VisibilityTimeout = d
+	}
+}
+
+// WithHeartbeatInterval sets how often a running job's lease is extended
+// automatically. Pass 0 to disable heartbeats, in which case long handlers
+// must call ExtendLease themselves.
+func WithHeartbeatInterval(d time.Duration) Option {
+	return func(s *Server) {
+		s.cfg.Worker.HeartbeatInterval = d
+	}
+}
+
+// WithQueue sets a queue's selection weight and its cluster-wide limit on
+// jobs processing at once (0 for unlimited). The settings are stored when
+// the server is created and apply to every node.
+func WithQueue(name string, weight int, maxInFlight int) Option {
+	return func(s *Server) {
+		if s.cfg.Queues == nil {
+			s.cfg.Queues = make(map[string]config.QueueConfig)
+		}
+		queue := s.cfg.Queues[name]
+		queue.Weight = weight
+		queue.MaxInFlight = maxInFlight
+		s.cfg.Queues[name] = queue
+	}
+}
+
+// WithQueueRetryPolicy sets how jobs in queue are retried. Fields left zero
+// fall back to the server's retry settings, and jobs may override it with
+// their own policy.
+func WithQueueRetryPolicy(name string, policy RetryPolicy) Option {
+	return func(s *Server) {
+		if s.cfg.Queues == nil {
+			s.cfg.Queues = make(map[string]config.QueueConfig)
+		}
+		queue := s.cfg.Queues[name]
+		queue.Retry = &policy
+		s.cfg.Queues[name] = queue
+	}
+}
+
+// WithHTTPAddr makes Run serve the API on addr. Without it the API is only
+// reachable through Handler.
+func WithHTTPAddr(addr string) Option {
+	return func(s *Server) {
+		s.httpAddr = addr
+	}
+}
+
+// WithHandler routes jobs in queue to handler.
+func WithHandler(queue string, handler HandlerFunc) Option {
+	return func(s *Server) {
+		s.registrations = append(s.registrations, func(d *dispatcher.Dispatcher) {
+			d.RegisterHandler(queue, handler)
+		})
+	}
+}
+
+// WithTypeHandler routes jobs of jobType to handler in any queue.
+func WithTypeHandler(jobType string, handler HandlerFunc) Option {
+	return func(s *Server) {
+		s.registrations = append(s.registrations, func(d *dispatcher.Dispatcher) {
+			d.RegisterTypeHandler(jobType, handler)
+		})
+	}
+}
+
+// WithDefaultHandler handles jobs that match no type or queue handler.
+func WithDefaultHandler(handler HandlerFunc) Option {
+	return func(s *Server) {
+		s.registrations = append(s.registrations, func(d *dispatcher.Dispatcher) {
+			d.RegisterDefaultHandler(handler)
+		})
+	}
+}
+
+// NewServer builds a Server. Nothing is started until Run is called.
+func NewServer(opts ...Option) (*Server, error) {
+	s := &Server{cfg: config.NewDefaultConfig()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("gosynq: invalid configuration: %w", err)
+	}
+
+	switch {
+	case s.repo != nil:
+	case s.db != nil:
+		s.repo = repository.NewPostgresRepository(s.db)
+	default:
+		store, db, err := repository.Open(context.Background(), s.cfg.Database)
+		if err != nil {
+			return nil, fmt.Errorf("gosynq: failed to open database: %w", err)
+		}
+		s.repo = store
+		s.ownedDB = db
+	}
+	// Only a database opened from cfg.Database has a connection string to
+	// listen with; other stores are polled
+	var listenDSN string
+	if s.ownedDB != nil && (s.cfg.Database.Driver == "" || s.cfg.Database.Driver == "postgres") {
+		listenDSN = repository.PostgresDSN(s.cfg.Database)
+	}
+	s.disp = dispatcher.NewDispatcher(s.repo, dispatcher.DispatcherConfig{
+		WorkerPoolSize:    s.cfg.Worker.PoolSize,
+		VisibilityTimeout: s.cfg.Worker.VisibilityTimeout,
+		RetryStrategy: worker.RetryStrategy{
+			Type:            s.cfg.Retries.DefaultStrategy,
+			Interval:        s.cfg.Retries.DefaultInterval,
+			MaxAttempts:     s.cfg.Retries.MaxAttempts,
+			ExponentialBase: s.cfg.Retries.ExponentialBase,
+			MaxDelay:        s.cfg.Retries.MaxDelay,
+			Jitter:          s.cfg.Retries.Jitter,
+		},
+		Queues:                s.cfg.Worker.Queues,
+		SchedulerPollInterval: s.cfg.Scheduler.PollInterval,
+		HeartbeatInterval:     s.cfg.Worker.HeartbeatInterval,
+		ReaperInterval:        s.cfg.Worker.ReaperInterval,
+		IdempotencyRetention:  s.cfg.Idempotency.Retention,
+		ListenDSN:             listenDSN,
+		PollInterval:          s.cfg.Worker.PollInterval,
+		Registerer:            s.registerer,
+	})
+	for _, register := range s.registrations {
+		register(s.disp)
+	}
+	for name, queue := range s.cfg.Queues {
+		if err := s.disp.ConfigureQueue(context.Background(), name, queue.Weight, queue.MaxInFlight); err != nil {
+			if s.ownedDB != nil {
+				s.ownedDB.Close()
+			}
+			return nil, fmt.Errorf("gosynq: queue %q: %w", name, err)
+		}
+		if queue.Retry == nil {
+			continue
+		}
+		if err := s.disp.SetQueueRetryPolicy(context.Background(), name, queue.Retry); err != nil {
+			if s.ownedDB != nil {
+				s.ownedDB.Close()
+			}
+			return nil, fmt.Errorf("gosynq: queue %q: %w", name, err)
+		}
+	}
+
+	s.wsServer = websocket.NewWebSocketServer(s.disp.GetEventChannel())
+	s.handler = api.NewRouter(s.disp, s.repo, s.wsServer, s.cfg.Admin)
+
+	if s.httpAddr != "" {
+		s.httpServer = &http.Server{
+			Addr:    s.httpAddr,
+			Handler: s.handler,
+		}
+	}
+
+	return s, nil
+}
+
+// Handler returns the HTTP API. All routes live under /api/v1; to mount
+// them under a prefix use http.StripPrefix.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Enqueue stores a job and hands it to the dispatcher. Missing IDs,
+// queues, priorities and run times are filled in, and a missing payload
+// becomes {}. If the job's idempotency key is already taken in its queue,
+// nothing is enqueued and job is overwritten with the job holding the key.
+func (s *Server) Enqueue(ctx context.Context, job *Job) error {
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+
+	existing, err := s.disp.EnqueueJob(ctx, job)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		*job = *existing
+	}
+	return nil
+}
+
+// EnqueueBatch stores jobs in one transaction, filling in defaults like
+// Enqueue. Every job whose idempotency key is already taken is overwritten
+// with the job holding the key. If any job is invalid, none is stored.
+func (s *Server) EnqueueBatch(ctx context.Context, jobs []*Job) error {
+	now := time.Now()
+	for _, job := range jobs {
+		if job.ID == "" {
+			job.ID = uuid.New().String()
+		}
+		if job.RunAt.IsZero() {
+			job.RunAt = now
+		}
+	}
+
+	existing, err := s.disp.EnqueueJobs(ctx, jobs)
+	if err != nil {
+		return err
+	}
+	for i, job := range existing {
+		if job != nil {
+			*jobs[i] = *job
+		}
+	}
+	return nil
+}
+
+// CreateSchedule stores a cron schedule that enqueues a copy of its job
+// template on every tick. Missing IDs, queues, priorities and timezones are
+// filled in.
+func (s *Server) CreateSchedule(ctx context.Context, schedule *Schedule) error {
+	return s.disp.Scheduler().CreateSchedule(ctx, schedule)
+}
+
+func (s *Server) start(ctx context.Context) {
+	s.startOnce.Do(func() {
+		s.wsServer.Start(ctx)
+		if !s.cfg.Server.APIOnly {
+			s.disp.Start(ctx)
+		}
+	})
+}
+
+// Run starts the workers unless the server is API-only, the event hub
+// and, if configured, the HTTP listener. It blocks until ctx is cancelled
+// or the listener fails, then shuts everything down.
+func (s *Server) Run(ctx context.Context) error {
+	s.start(ctx)
+
+	errCh := make(chan error, 1)
+	if s.httpServer != nil {
+		go func() {
+			log.Printf("Server starting on %s", s.httpServer.Addr)
+			if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errCh <- fmt.Errorf("HTTP server error: %w", err)
+			}
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case runErr = <-errCh:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := s.Shutdown(shutdownCtx); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+// Shutdown stops the HTTP listener, waits for in-flight jobs and closes
+// WebSocket clients. Enqueueing afterwards fails with ErrServerClosed. It is
+// safe to call more than once.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		log.Println("Shutting down server...")
+
+		if s.httpServer != nil {
+			if err := s.httpServer.Shutdown(ctx); err != nil {
+				s.shutdownErr = fmt.Errorf("HTTP server shutdown error: %w", err)
+			}
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.disp.Shutdown()
+			s.wsServer.Shutdown()
+			if s.ownedDB != nil {
+				s.ownedDB.Close()
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if s.shutdownErr == nil {
+				s.shutdownErr = ctx.Err()
+			}
+		}
+	})
+	return s.shutdownErr
+}